@@ -0,0 +1,81 @@
+// Command msgraph-gen generates Go types, and optionally msgraph.ResourceAPI implementations, from a Graph
+// $metadata CSDL document. It is run by go generate, see pkg/models.
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"westpac.co.nz/msgraph/pkg/csdl"
+	"westpac.co.nz/msgraph/pkg/helpers"
+)
+
+func main() {
+	app := &cli.App{
+		Name:  "msgraph-gen",
+		Usage: "Generate resource types from Graph CSDL $metadata",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "metadata",
+				Usage:    "the CSDL XML document, a copy of https://graph.microsoft.com/v1.0/$metadata",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "package",
+				Usage: "the package name of the generated file",
+				Value: "models",
+			},
+			&cli.StringFlag{
+				Name:     "sets",
+				Usage:    "comma separated entity sets to generate, e.g. users,groups",
+				Required: true,
+			},
+			&cli.BoolFlag{
+				Name:  "resources",
+				Usage: "also generate a msgraph.ResourceAPI per entity set",
+			},
+			&cli.StringFlag{
+				Name:  "out",
+				Usage: "the generated file, standard output when not set",
+			},
+		},
+		Action: func(context *cli.Context) error {
+			generate(*context)
+			return nil
+		},
+	}
+
+	err := app.Run(os.Args)
+	helpers.ErrorHandlerFatal("", err)
+}
+
+func generate(context cli.Context) {
+	file, err := os.Open(context.String("metadata"))
+	helpers.ErrorHandlerFatal("Reading metadata failed: ", err)
+	defer file.Close()
+
+	metadata, err := csdl.Parse(file)
+	helpers.ErrorHandlerFatal("Parsing metadata failed: ", err)
+
+	var sets []string
+	for _, set := range strings.Split(context.String("sets"), ",") {
+		sets = append(sets, strings.TrimSpace(set))
+	}
+	source, err := csdl.Generate(metadata, csdl.Config{
+		Package:    context.String("package"),
+		Source:     context.String("metadata"),
+		EntitySets: sets,
+		Resources:  context.Bool("resources"),
+	})
+	helpers.ErrorHandlerFatal("Generating failed: ", err)
+
+	if !context.IsSet("out") {
+		_, err = os.Stdout.Write(source)
+		helpers.ErrorHandlerFatal("Writing failed: ", err)
+		return
+	}
+	err = ioutil.WriteFile(context.String("out"), source, 0644)
+	helpers.ErrorHandlerFatal("Writing failed: ", err)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"westpac.co.nz/msgraph/pkg/helpers"
+	"westpac.co.nz/msgraph/pkg/msgraph"
+	"westpac.co.nz/msgraph/pkg/resources"
+)
+
+// appRoleAssignmentsCommand builds the 'approles' sub command tree for the principal collection
+// ('users', 'groups' or 'servicePrincipals')
+func appRoleAssignmentsCommand(principalType string) *cli.Command {
+	resourceAPI := resources.AppRoleAssignmentsResource{PrincipalType: principalType}
+
+	return &cli.Command{
+		Name:        "approles",
+		Aliases:     []string{"r"},
+		Usage:       fmt.Sprintf("The app role assignments of %s", principalType),
+		Description: "List, grant and revoke app role assignments",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "list",
+				Aliases:   []string{"l"},
+				Usage:     "list the app roles assigned to the principal",
+				ArgsUsage: "<principal id>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "resource",
+						Usage: "only list assignments on this resource service principal object id",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					setVerbosity(c)
+					if c.NArg() < 1 {
+						return cli.Exit("missing principal id", 1)
+					}
+					baseResource := newBaseResource(*c)
+
+					assignments, err := baseResource.List(c.Context, resourceAPI, *c, c.Args())
+					helpers.ErrorHandlerFatal("Listing app role assignments failed: ", err)
+					log.Debug("Fetched resource:", len(assignments))
+
+					display(assignments, *c)
+					return nil
+				},
+			},
+			{
+				Name:      "grant",
+				Aliases:   []string{"g"},
+				Usage:     "assign an app role of a resource service principal to the principal",
+				ArgsUsage: "<principal id>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "resource",
+						Usage:    "the resource service principal: object id, appId or display name",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "role",
+						Usage: "the app role id, value or display name, the default access role when omitted",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					setVerbosity(c)
+					if c.NArg() < 1 {
+						return cli.Exit("missing principal id", 1)
+					}
+					baseResource := newBaseResource(*c)
+
+					request, err := resourceAPI.NewAssignment(c.Context, baseResource, c.Args().Get(0), c.String("resource"), c.String("role"))
+					helpers.ErrorHandlerFatal("Granting app role failed: ", err)
+					assignment, err := baseResource.Create(c.Context, resourceAPI, *c, c.Args(), request)
+					helpers.ErrorHandlerFatal("Granting app role failed: ", err)
+
+					display([]msgraph.Resource{assignment}, *c)
+					return nil
+				},
+			},
+			{
+				Name:      "revoke",
+				Usage:     "remove an app role assignment from the principal",
+				ArgsUsage: "<principal id> <assignment id>",
+				Action: func(c *cli.Context) error {
+					setVerbosity(c)
+					if c.NArg() < 2 {
+						return cli.Exit("missing principal id or assignment id", 1)
+					}
+					baseResource := newBaseResource(*c)
+
+					err := baseResource.Delete(c.Context, resourceAPI.CreateDeletePath(*c, c.Args()))
+					helpers.ErrorHandlerFatal("Revoking app role assignment failed: ", err)
+					log.Infof("Revoked app role assignment %s", c.Args().Get(1))
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func applicationPermissions(c *cli.Context) error {
+	setVerbosity(c)
+	if c.NArg() < 1 {
+		return cli.Exit("missing application id, appId or display name", 1)
+	}
+	baseResource := newBaseResource(*c)
+
+	applicationsAPI := resources.ApplicationsResource{}
+	application, found, err := applicationsAPI.Find(c.Context, baseResource, c.Args().Get(0))
+	helpers.ErrorHandlerFatal("Looking up application failed: ", err)
+	if !found {
+		return cli.Exit(fmt.Sprintf("no application found for '%s'", c.Args().Get(0)), 1)
+	}
+
+	permissions, err := applicationsAPI.Permissions(c.Context, baseResource, application)
+	helpers.ErrorHandlerFatal("Resolving permissions failed: ", err)
+	display(permissions, *c)
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"westpac.co.nz/msgraph/pkg/helpers"
+	"westpac.co.nz/msgraph/pkg/resources"
+)
+
+// oauth2PermissionGrantsCommands the sub commands auditing and revoking delegated permission grants
+func oauth2PermissionGrantsCommands() []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:      "audit",
+			Aliases:   []string{"a"},
+			Usage:     "report every grant with its consent type, consenting user and high risk scopes",
+			ArgsUsage: "[filter - client service principal object id]",
+			Flags: []cli.Flag{
+				&cli.StringSliceFlag{
+					Name:  "risk-scopes",
+					Usage: "scopes considered high risk",
+					Value: cli.NewStringSlice(resources.DefaultRiskScopes...),
+				},
+				&cli.StringFlag{
+					Name:  "risk-scopes-file",
+					Usage: "file with one high risk scope per line, replaces --risk-scopes",
+				},
+				&cli.BoolFlag{
+					Name:  "risky-only",
+					Usage: "only report grants containing a high risk scope",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				setVerbosity(c)
+				baseResource := newBaseResource(*c)
+
+				riskScopes := c.StringSlice("risk-scopes")
+				if c.IsSet("risk-scopes-file") {
+					riskScopes = readLines(c.String("risk-scopes-file"))
+				}
+				log.Debug("Risk scopes:", riskScopes)
+
+				grantsAPI := resources.OAuth2PermissionGrantsResource{}
+				grants, err := baseResource.List(c.Context, grantsAPI, *c, c.Args())
+				helpers.ErrorHandlerFatal("Listing grants failed: ", err)
+				log.Debug("Fetched resource:", len(grants))
+
+				audits, err := grantsAPI.Audit(c.Context, baseResource, grants, riskScopes)
+				helpers.ErrorHandlerFatal("Auditing grants failed: ", err)
+				if c.Bool("risky-only") {
+					risky := audits[:0]
+					for _, audit := range audits {
+						if audit.(resources.OAuth2PermissionGrantAudit).HighRisk {
+							risky = append(risky, audit)
+						}
+					}
+					audits = risky
+				}
+
+				display(audits, *c)
+				return nil
+			},
+		},
+		{
+			Name:      "revoke",
+			Usage:     "delete a delegated permission grant, or remove only some of its scopes",
+			ArgsUsage: "<grant id>",
+			Flags: []cli.Flag{
+				&cli.StringSliceFlag{
+					Name:  "scope",
+					Usage: "only revoke this scope, can be repeated",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				setVerbosity(c)
+				if c.NArg() < 1 {
+					return cli.Exit("missing grant id", 1)
+				}
+				baseResource := newBaseResource(*c)
+
+				grantsAPI := resources.OAuth2PermissionGrantsResource{}
+				err := grantsAPI.Revoke(c.Context, baseResource, c.Args().Get(0), c.StringSlice("scope"))
+				helpers.ErrorHandlerFatal("Revoking grant failed: ", err)
+				log.Infof("Revoked delegated permission grant %s", c.Args().Get(0))
+				return nil
+			},
+		},
+	}
+}
+
+// readLines returns the non empty, non comment lines of the file
+func readLines(fileName string) []string {
+	file, err := os.Open(fileName)
+	helpers.ErrorHandlerFatal("Could not open file ", err)
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	helpers.ErrorHandlerFatal("Could not read file ", scanner.Err())
+	return lines
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"westpac.co.nz/msgraph/pkg/helpers"
+	"westpac.co.nz/msgraph/pkg/msgraph"
+	"westpac.co.nz/msgraph/pkg/resources"
+)
+
+// groupOwners lists the groups with their owners, looking up the owners of --concurrency groups at a time
+func groupOwners(c *cli.Context) error {
+	setVerbosity(c)
+	baseResource := newBaseResource(*c)
+
+	groupsAPI := resources.GroupsResource{}
+	groups, err := baseResource.List(c.Context, groupsAPI, *c, c.Args())
+	helpers.ErrorHandlerFatal("Listing groups failed: ", err)
+	log.Debug("Fetched resource:", len(groups))
+
+	fanOut := msgraph.FanOut{Concurrency: c.Int("concurrency"), Ordered: true, CollectErrors: c.Bool("keep-going")}
+	var owners []msgraph.Resource
+	err = fanOut.Run(c.Context, msgraph.Resources(groups), func(ctx context.Context, group msgraph.Resource) (msgraph.Resource, error) {
+		return groupsAPI.Owners(ctx, baseResource, group.(resources.GraphAPIV1GroupResponse))
+	}, func(resource msgraph.Resource) {
+		owners = append(owners, resource)
+	})
+
+	// the groups looked up before an error or cancellation are still shown
+	if err == nil || len(owners) > 0 {
+		display(owners, *c)
+	}
+	helpers.ErrorHandlerFatal("Looking up owners failed: ", err)
+	return nil
+}
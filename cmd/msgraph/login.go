@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/oauth2"
+	"westpac.co.nz/msgraph/pkg/helpers"
+	"westpac.co.nz/msgraph/pkg/msauth"
+	"westpac.co.nz/msgraph/pkg/msgraph"
+	"westpac.co.nz/msgraph/pkg/resources"
+)
+
+// loginCommands the 'login', 'logout' and 'whoami' commands signing in with the engineer's own identity
+func loginCommands() []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:        "login",
+			Usage:       "sign in as a user, --clientID must be a public client application",
+			Description: "Signs in with the browser (authorization code with PKCE) or a device code and caches the refresh token",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "device-code",
+					Usage: "sign in with a device code instead of opening a browser, for terminals without one",
+				},
+				&cli.StringSliceFlag{
+					Name:  "scopes",
+					Usage: "the delegated scopes to request, by default all consented Graph scopes and a refresh token",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				setVerbosity(c)
+				tenant := c.String("tenant")
+				if tenant == "" || c.String("clientID") == "" {
+					return cli.Exit("login needs --tenant and --clientID", 1)
+				}
+				azureCloud := selectedCloud(*c)
+				scopes := azureCloud.DelegatedScopes()
+				if c.IsSet("scopes") {
+					scopes = c.StringSlice("scopes")
+				}
+				config := msauth.NewDelegatedConfig(azureCloud.AuthorityHost, tenant, c.String("clientID"), scopes)
+
+				var token *oauth2.Token
+				var err error
+				if c.Bool("device-code") {
+					token, err = msauth.DeviceCodeLogin(c.Context, config, func(code msauth.DeviceCode) {
+						fmt.Fprintln(c.App.ErrWriter, code.Message)
+					})
+				} else {
+					token, err = msauth.InteractiveLogin(c.Context, config, func(url string) error {
+						fmt.Fprintf(c.App.ErrWriter, "Sign in at: %s\n", url)
+						if err := msauth.OpenBrowser(url); err != nil {
+							log.Debug("Could not open browser: ", err)
+						}
+						return nil
+					})
+				}
+				helpers.ErrorHandlerFatal("Login failed: ", err)
+
+				accounts, err := msauth.LoadAccounts(msauth.DefaultAccountsPath())
+				helpers.ErrorHandlerFatal("Could not read accounts cache: ", err)
+
+				username := msauth.TokenUsername(token)
+				accounts.Add(msauth.Account{
+					Tenant:        tenant,
+					ClientID:      c.String("clientID"),
+					Username:      username,
+					AuthorityHost: azureCloud.AuthorityHost,
+					Token:         token,
+				})
+				helpers.ErrorHandlerFatal("Could not save accounts cache: ", accounts.Save())
+
+				fmt.Fprintf(c.App.Writer, "Logged in as %s\n", username)
+				return nil
+			},
+		},
+		{
+			Name:  "logout",
+			Usage: "remove the cached sign in of the current (or --account) user of the tenant",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "all",
+					Usage: "remove every cached user of the tenant",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				setVerbosity(c)
+				accounts, err := msauth.LoadAccounts(msauth.DefaultAccountsPath())
+				helpers.ErrorHandlerFatal("Could not read accounts cache: ", err)
+
+				username := c.String("account")
+				if c.Bool("all") {
+					username = ""
+				} else if username == "" {
+					username = accounts.Current[c.String("tenant")]
+					if username == "" {
+						return cli.Exit("not logged in", 1)
+					}
+				}
+
+				accounts.Remove(c.String("tenant"), username)
+				helpers.ErrorHandlerFatal("Could not save accounts cache: ", accounts.Save())
+				log.Info("Logged out ", username)
+				return nil
+			},
+		},
+		{
+			Name:  "whoami",
+			Usage: "show the signed in user",
+			Action: func(c *cli.Context) error {
+				setVerbosity(c)
+				baseResource := newBaseResource(*c)
+
+				body, err := baseResource.Request(c.Context, "GET", "/me", nil, nil)
+				helpers.ErrorHandlerFatal("Retrieving the signed in user failed: ", err)
+				me := resources.UsersResource{}.ConvertToResource(body)
+
+				display([]msgraph.Resource{me}, *c)
+				return nil
+			},
+		},
+	}
+}
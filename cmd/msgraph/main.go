@@ -1,77 +1,242 @@
 package main
 
 import (
+	"bytes"
+	ctx "context"
 	"encoding/json"
 	"fmt"
-	"github.com/rodaine/table"
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/oauth2"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
-	"reflect"
+	"os/signal"
 	"strings"
+	"time"
+	"westpac.co.nz/msgraph/pkg/cloud"
 	"westpac.co.nz/msgraph/pkg/helpers"
+	"westpac.co.nz/msgraph/pkg/httplog"
 	"westpac.co.nz/msgraph/pkg/msauth"
 	"westpac.co.nz/msgraph/pkg/msgraph"
+	"westpac.co.nz/msgraph/pkg/output"
+	"westpac.co.nz/msgraph/pkg/recording"
+	"westpac.co.nz/msgraph/pkg/redact"
 	"westpac.co.nz/msgraph/pkg/resources"
-	"westpac.co.nz/msgraph/pkg/slices"
 )
 
-var resourceMap map[string]msgraph.ResourceAPI
-
 func init() {
 
 	// Log as JSON instead of the default ASCII formatter.
 	log.SetFormatter(&log.TextFormatter{})
 
-	// Output to stdout instead of the default stderr
-	// Can be any io.Writer, see below for File example
-	log.SetOutput(os.Stdout)
+	// Output to stderr so logs never mix with the command output
+	log.SetOutput(os.Stderr)
 
 	// Only log the warning severity or above.
 	log.SetLevel(log.ErrorLevel)
+}
 
-	resourceMap = make(map[string]msgraph.ResourceAPI)
-
-	var groupsAPI msgraph.ResourceAPI = resources.GroupsResource{}
-	var usersAPI msgraph.ResourceAPI = resources.UsersResource{}
-	var applicationsAPI msgraph.ResourceAPI = resources.ApplicationsResource{}
+func stringCompare(item1 interface{}, item2 interface{}) bool {
+	return strings.ToLower(string(item1.(string))) == strings.ToLower(string(item2.(string)))
+}
 
-	resourceMap["groups"] = groupsAPI
-	resourceMap["users"] = usersAPI
-	resourceMap["applications"] = applicationsAPI
+// oneOf reports whether value is in values, ignoring case
+func oneOf(value string, values []string) bool {
+	for _, candidate := range values {
+		if stringCompare(value, candidate) {
+			return true
+		}
+	}
+	return false
+}
 
+func setVerbosity(c *cli.Context) {
+	if c.IsSet("verbose") {
+		level, err := log.ParseLevel(c.String("verbose"))
+		helpers.ErrorHandlerFatal("Could not parse verbosity ", err)
+		log.SetLevel(level)
+	}
 }
 
-func stringCompare(item1 interface{}, item2 interface{}) bool {
-	return strings.ToLower(string(item1.(string))) == strings.ToLower(string(item2.(string)))
+// setLogFormat applies --log-format
+func setLogFormat(c *cli.Context) error {
+	switch c.String("log-format") {
+	case "text":
+		log.SetFormatter(&log.TextFormatter{})
+	case "json":
+		log.SetFormatter(&log.JSONFormatter{})
+	default:
+		return cli.Exit(fmt.Sprintf("Invalid log format '%s', expected text or json", c.String("log-format")), 1)
+	}
+	return nil
 }
 
-func list(resourceName string, tenantID string, clientID string, clientSecret string, context cli.Context, args cli.Args) {
+func newBaseResource(context cli.Context) msgraph.BaseResource {
+
+	version := strings.ToLower(context.String("api-version"))
+	if !oneOf(version, msgraph.Versions) {
+		log.Fatalf("Invalid API version '%s', expected one of %s", version, msgraph.Versions)
+	}
+	azureCloud := selectedCloud(context)
+
+	properties, scopes := redaction(context)
+	redactor := redact.New(properties, scopes...)
+	options := []msgraph.Option{
+		msgraph.WithVersion(version),
+		msgraph.WithBaseURL(azureCloud.GraphURL),
+		msgraph.WithUserAgent(fmt.Sprintf("msgraph-cli/%s", context.App.Version)),
+		msgraph.WithRequestTimeout(context.Duration("request-timeout")),
+		msgraph.WithRedactedProperties(properties...),
+		msgraph.WithRedactionScopes(scopes...),
+	}
+	if context.Float64("rate") > 0 {
+		options = append(options, msgraph.WithRateLimiter(msgraph.NewTokenBucket(context.Float64("rate"), context.Int("burst"))))
+	}
+
+	if context.IsSet("replay") {
+		replayer, err := recording.NewReplayer(context.String("replay"), redactor)
+		helpers.ErrorHandlerFatal("Loading fixtures failed: ", err)
+		return msgraph.NewClient(append(options, msgraph.WithTransport(replayer))...).BaseResource
+	}
 
 	log.Debug("Retrieving token...")
 
-	var baseResource = msgraph.BaseResource{
-		HTTPClient: msauth.GetOAuth2Client(tenantID, clientID, clientSecret),
-		Version:    "v1.0",
+	credentials := msauth.CredentialsFromEnvironment()
+	credentials.Method = context.String("auth")
+	credentials.Tenant = context.String("tenant")
+	credentials.ClientID = context.String("clientID")
+	credentials.ClientSecret = context.String("clientSecret")
+	credentials.CertFile = context.String("cert")
+	credentials.KeyFile = context.String("key")
+	credentials.CertPassword = context.String("certPassword")
+	credentials.FederatedTokenFile = context.String("federatedTokenFile")
+	credentials.ManagedIdentityEndpoint = context.String("msiEndpoint")
+	credentials.Account = context.String("account")
+	credentials.Cache = newTokenCache(context)
+
+	credentials.AuthorityHost = azureCloud.AuthorityHost
+	credentials.Scopes = azureCloud.Scopes()
+
+	// token requests carry client secrets and assertions, log them redacted as well
+	tokenClient := &http.Client{Transport: &httplog.Transport{Redactor: redactor}}
+	tokenContext := ctx.WithValue(context.Context, oauth2.HTTPClient, tokenClient)
+	source, err := msauth.NewTokenSource(tokenContext, credentials)
+	helpers.ErrorHandlerFatal("Authentication failed: ", err)
+	options = append(options, msgraph.WithTokenSource(source))
+
+	if context.IsSet("record") {
+		recorder, err := recording.NewRecorder(context.String("record"), http.DefaultTransport, redactor)
+		helpers.ErrorHandlerFatal("Recording fixtures failed: ", err)
+		options = append(options, msgraph.WithTransport(recorder))
+	}
+
+	return msgraph.NewClient(options...).BaseResource
+}
+
+// redaction the JSON properties redacted from recorded fixtures and request logs: secrets and those given
+// with --redact everywhere, the properties resources tag as personal data from the objects of their type
+func redaction(context cli.Context) ([]string, []redact.Scope) {
+	properties := append([]string{}, redact.DefaultProperties...)
+	scopes := []redact.Scope{{
+		Collections: []string{"appRoleAssignments", "appRoleAssignedTo"},
+		ODataType:   "#microsoft.graph.appRoleAssignment",
+		Properties:  msgraph.PIIPropertyNames(resources.GraphAPIV1AppRoleAssignmentResponse{}),
+	}}
+	for _, registration := range msgraph.Registrations() {
+		scopes = append(scopes, redact.Scope{
+			Collections: []string{registration.Name},
+			ODataType:   registration.ODataType,
+			Properties:  msgraph.PIIPropertyNames(registration.API.ConvertToResource([]byte("{}"))),
+		})
 	}
+	if context.IsSet("redact") {
+		for _, name := range strings.Split(context.String("redact"), ",") {
+			properties = append(properties, strings.TrimSpace(name))
+		}
+	}
+	return properties, scopes
+}
 
-	resourceAPI := resourceMap[resourceName]
+// selectedCloud the --cloud endpoints with the --graph-url and --authority-host overrides applied
+func selectedCloud(context cli.Context) cloud.Cloud {
+	azureCloud, err := cloud.Lookup(context.String("cloud"))
+	helpers.ErrorHandlerFatal("Invalid cloud: ", err)
 
-	resources := baseResource.List(resourceAPI, context, args)
+	azureCloud = azureCloud.WithOverrides(context.String("graph-url"), context.String("authority-host"))
+	log.Debugf("Using cloud %s: graph %s, authority %s", azureCloud.Name, azureCloud.GraphURL, azureCloud.AuthorityHost)
+	return azureCloud
+}
 
-	log.Debug("Fetched resource:", len(resources))
+// newTokenCache the on disk token cache, nil when disabled or no passphrase is configured
+func newTokenCache(context cli.Context) *msauth.TokenCache {
+	if context.Bool("no-token-cache") {
+		return nil
+	}
+
+	passphrase := os.Getenv(msauth.TokenCacheKeyEnv)
+	if context.IsSet("token-cache-key-file") {
+		var err error
+		passphrase, err = msauth.ReadPassphraseFile(context.String("token-cache-key-file"))
+		helpers.ErrorHandlerFatal("Could not read token cache passphrase ", err)
+	}
+	if passphrase == "" {
+		log.Debug("Token cache disabled, no passphrase")
+		return nil
+	}
+
+	return msauth.NewTokenCache(context.String("token-cache"), passphrase)
+}
+
+// list shows the resources, filtered by the args and the where criteria when not nil
+func list(resourceAPI msgraph.ResourceAPI, context cli.Context, args cli.Args, where *msgraph.Criteria) {
 
-	var fields = []interface{}{}
+	var baseResource = newBaseResource(context)
+
+	path := resourceAPI.CreateRequestPath(context, args)
+	params := resourceAPI.CreateQueryParams(context, args)
+	if where != nil {
+		if params == nil {
+			params = url.Values{}
+		}
+		filter := (*where).String()
+		if params.Get("$filter") != "" {
+			filter = params.Get("$filter") + " and " + filter
+		}
+		params.Set("$filter", filter)
+	}
 	if context.IsSet("fields") {
-		untrimmedFields := strings.Split(context.String("fields"), ",")
-		for index := range untrimmedFields {
-			fields = append(fields, strings.TrimSpace(untrimmedFields[index]))
+		// properties outside the default set are only returned when selected
+		if params == nil {
+			params = url.Values{}
 		}
-		log.Debug("FIELDS", fields)
+		params.Set("$select", msgraph.SelectQuery(resourceAPI, fieldNames(context)))
+	}
+
+	resources, err := baseResource.ListPath(context.Context, resourceAPI, path, params)
+
+	log.Debug("Fetched resource:", len(resources))
+
+	// on cancellation or a failed page the pages retrieved so far are still shown
+	if err == nil || len(resources) > 0 {
+		display(resources, context)
+	}
+	helpers.ErrorHandlerFatal("Listing failed: ", err)
+}
+
+func display(resources []msgraph.Resource, context cli.Context) {
+
+	log.Debug("FIELDS", fieldNames(context))
+
+	// template formats carry their argument: -o template='{{.DisplayName}}'
+	format, argument := context.String("output"), ""
+	if index := strings.Index(format, "="); index >= 0 {
+		format, argument = format[:index], format[index+1:]
 	}
 
 	var resourceStr string = ""
-	switch context.String("output") {
+	switch format {
 	case "json":
 		resourceBytes, err := json.Marshal(resources)
 		helpers.ErrorHandlerFatal("Unable to Marshall resource to JSON", err)
@@ -81,50 +246,92 @@ func list(resourceName string, tenantID string, clientID string, clientSecret st
 			resourceStr += fmt.Sprintf("%+v\n", resource)
 		}
 	case "table":
-		var tbl table.Table
-		headerDisplayed := false
-		for _, resource := range resources {
-
-			displayFields := slices.Union(getResourceFields(resource), fields, stringCompare)
-			log.Debug("FIELDS", displayFields)
-
-			if !headerDisplayed {
-				tbl = table.New(displayFields...)
-				headerDisplayed = true
-			}
+		width := 0
+		if file, ok := context.App.Writer.(*os.File); ok && !context.Bool("wide") {
+			width = output.TerminalWidth(file)
+		}
+		columns := output.TableColumns(resources, fieldNames(context), context.Bool("wide"))
+		log.Debug("COLUMNS", columns)
 
-			values := getResourceValues(resource, displayFields)
-			tbl.AddRow(values...)
+		var buffer bytes.Buffer
+		err := output.WriteTable(&buffer, resources, columns, width)
+		helpers.ErrorHandlerFatal("Unable to write resources as table", err)
+		resourceStr = strings.TrimSuffix(buffer.String(), "\n")
+	case "markdown", "html":
+		columns := output.TableColumns(resources, fieldNames(context), context.Bool("wide"))
+		var buffer bytes.Buffer
+		var err error
+		if format == "markdown" {
+			err = output.WriteMarkdown(&buffer, resources, columns)
+		} else {
+			err = output.WriteHTML(&buffer, resources, columns, output.Report{
+				Title:     context.String("title"),
+				Tenant:    context.String("tenant"),
+				Generated: time.Now(),
+			})
 		}
-		tbl.Print()
+		helpers.ErrorHandlerFatal("Unable to write resources as "+format, err)
+		resourceStr = strings.TrimSuffix(buffer.String(), "\n")
+	case "csv", "tsv":
+		comma := ','
+		if format == "tsv" {
+			comma = '\t'
+		}
+		var buffer bytes.Buffer
+		err := output.WriteDelimited(&buffer, resources, fieldNames(context), comma, context.String("separator"))
+		helpers.ErrorHandlerFatal("Unable to write resources as "+format, err)
+		resourceStr = strings.TrimSuffix(buffer.String(), "\n")
+	case "yaml", "ndjson":
+		var buffer bytes.Buffer
+		var err error
+		if format == "yaml" {
+			err = output.WriteYAML(&buffer, resources, fieldNames(context))
+		} else {
+			err = output.WriteNDJSON(&buffer, resources, fieldNames(context))
+		}
+		helpers.ErrorHandlerFatal("Unable to write resources as "+format, err)
+		resourceStr = strings.TrimSuffix(buffer.String(), "\n")
+	case "template", "template-file":
+		text := argument
+		if format == "template-file" {
+			content, err := ioutil.ReadFile(argument)
+			helpers.ErrorHandlerFatal("Unable to read template file "+argument, err)
+			text = string(content)
+		}
+		tmpl, err := output.NewTemplate(text)
+		helpers.ErrorHandlerFatal("Unable to parse template", err)
+		var buffer bytes.Buffer
+		err = output.WriteTemplate(&buffer, tmpl, resources, context.Bool("collection"))
+		helpers.ErrorHandlerFatal("Unable to execute template", err)
+		resourceStr = strings.TrimSuffix(buffer.String(), "\n")
+	case "jsonpath":
+		path, err := output.ParseJSONPath(argument)
+		helpers.ErrorHandlerFatal("Unable to parse jsonpath", err)
+		var buffer bytes.Buffer
+		err = output.WriteJSONPath(&buffer, path, resources, context.Bool("collection"))
+		helpers.ErrorHandlerFatal("Unable to evaluate jsonpath", err)
+		resourceStr = strings.TrimSuffix(buffer.String(), "\n")
 	case "string":
 		for _, resource := range resources {
 			resourceStr += fmt.Sprintf("%s\n", resource.ToString())
 		}
 	}
-	fmt.Println(resourceStr)
-}
-
-func getResourceValues(resource msgraph.Resource, headers []interface{}) []interface{} {
-	var values []interface{}
-	reflected := reflect.ValueOf(resource)
-	for _, fieldName := range headers {
-		values = append(values, reflected.FieldByName(fieldName.(string)))
-	}
-	return values
+	fmt.Fprintln(context.App.Writer, resourceStr)
 }
 
-func getResourceFields(resource msgraph.Resource) []interface{} {
-	var headers = make([]interface{}, 0)
-	reflected := reflect.ValueOf(resource)
-	for i := 0; i < reflected.NumField(); i++ {
-		fieldName := reflected.Type().Field(i).Name
-		headers = append(headers, fieldName)
+// fieldNames the property names given with --fields
+func fieldNames(context cli.Context) []string {
+	var names []string
+	if context.IsSet("fields") {
+		for _, name := range strings.Split(context.String("fields"), ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
 	}
-	return headers
+	return names
 }
 
-func main() {
+// newApp the msgraph command line application
+func newApp() *cli.App {
 
 	var app = &cli.App{
 		Usage:   "Azure MSGraph API",
@@ -139,22 +346,122 @@ func main() {
 				Name:     "tenant",
 				Aliases:  []string{"t"},
 				Usage:    "The Azure tenant ID to use for the query",
-				EnvVars:  []string{"AZ_TENANT"},
-				Required: true,
+				EnvVars:  []string{"AZ_TENANT", "AZURE_TENANT_ID"},
+				Required: false,
 			},
 			&cli.StringFlag{
 				Name:     "clientID",
 				Aliases:  []string{"c"},
-				Usage:    "The Azure client ID for the SPN",
-				EnvVars:  []string{"AZ_CLIENTID"},
-				Required: true,
+				Usage:    "The Azure client ID for the SPN, workload identity or user assigned managed identity",
+				EnvVars:  []string{"AZ_CLIENTID", "AZURE_CLIENT_ID"},
+				Required: false,
 			},
 			&cli.StringFlag{
 				Name:     "clientSecret",
 				Aliases:  []string{"s"},
 				Usage:    "The Azure client Secret for the SPN",
-				EnvVars:  []string{"AZ_CLIENTSECRET"},
-				Required: true,
+				EnvVars:  []string{"AZ_CLIENTSECRET", "AZURE_CLIENT_SECRET"},
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "cert",
+				Usage:    "PEM or PFX (.pfx/.p12) client certificate file for the SPN, used instead of a client secret",
+				EnvVars:  []string{"AZ_CLIENTCERT", "AZURE_CLIENT_CERTIFICATE_PATH"},
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "key",
+				Usage:    "PEM private key file for --cert, when the key is not in the certificate file",
+				EnvVars:  []string{"AZ_CLIENTKEY"},
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "certPassword",
+				Usage:    "password of the PFX client certificate",
+				EnvVars:  []string{"AZ_CLIENTCERT_PASSWORD", "AZURE_CLIENT_CERTIFICATE_PASSWORD"},
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "cloud",
+				Usage:    fmt.Sprintf("The Azure cloud: (%s)", cloud.Names()),
+				EnvVars:  []string{"MSGRAPH_CLOUD"},
+				Required: false,
+				Value:    cloud.Public.Name,
+			},
+			&cli.StringFlag{
+				Name:     "graph-url",
+				Usage:    "Custom Graph API endpoint, overrides the --cloud one",
+				EnvVars:  []string{"MSGRAPH_GRAPH_URL"},
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "authority-host",
+				Usage:    "Custom login (authority) host, overrides the --cloud one",
+				EnvVars:  []string{"AZURE_AUTHORITY_HOST"},
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "api-version",
+				Usage:    fmt.Sprintf("Graph API version: (%s)", msgraph.Versions),
+				EnvVars:  []string{"MSGRAPH_API_VERSION"},
+				Required: false,
+				Value:    msgraph.DefaultVersion,
+			},
+			&cli.StringFlag{
+				Name:     "auth",
+				Usage:    fmt.Sprintf("Authentication method: (%s)", msauth.Methods),
+				EnvVars:  []string{"MSGRAPH_AUTH"},
+				Required: false,
+				Value:    msauth.MethodAuto,
+			},
+			&cli.StringFlag{
+				Name:     "federatedTokenFile",
+				Usage:    "Workload identity federated token file",
+				EnvVars:  []string{"AZURE_FEDERATED_TOKEN_FILE"},
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "msiEndpoint",
+				Usage:    "Managed identity token endpoint",
+				EnvVars:  []string{"MSGRAPH_MSI_ENDPOINT", "IDENTITY_ENDPOINT"},
+				Required: false,
+				Value:    msauth.DefaultManagedIdentityEndpoint,
+			},
+			&cli.StringFlag{
+				Name:     "account",
+				Usage:    "The signed in user to act as when no SPN credentials are given, defaults to the last login",
+				EnvVars:  []string{"AZ_ACCOUNT"},
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:     "token-cache",
+				Usage:    fmt.Sprintf("Encrypted access token cache file, enabled when a passphrase is set in %s or --token-cache-key-file", msauth.TokenCacheKeyEnv),
+				EnvVars:  []string{"MSGRAPH_TOKEN_CACHE"},
+				Required: false,
+				Value:    msauth.DefaultTokenCachePath(),
+			},
+			&cli.StringFlag{
+				Name:     "token-cache-key-file",
+				Usage:    "File holding the token cache passphrase",
+				EnvVars:  []string{"MSGRAPH_TOKEN_CACHE_KEY_FILE"},
+				Required: false,
+			},
+			&cli.BoolFlag{
+				Name:     "no-token-cache",
+				Usage:    "Always request a new access token",
+				Required: false,
+			},
+			&cli.StringFlag{
+				Name:  "record",
+				Usage: "write every Graph request and response to a fixture file in this directory, with secrets and personal data redacted",
+			},
+			&cli.StringFlag{
+				Name:  "replay",
+				Usage: "answer Graph requests from the fixtures recorded in this directory, without network access",
+			},
+			&cli.StringFlag{
+				Name:  "redact",
+				Usage: "comma separated JSON properties to redact from recorded fixtures and request logs, in addition to secrets and personal data",
 			},
 			&cli.StringFlag{
 				Name:     "verbose",
@@ -163,13 +470,65 @@ func main() {
 				Required: false,
 				Value:    log.InfoLevel.String(),
 			},
+			&cli.StringFlag{
+				Name:    "log-format",
+				Usage:   fmt.Sprintf("format of the logs written to stderr: (%s)", []string{"text", "json"}),
+				EnvVars: []string{"MSGRAPH_LOG_FORMAT"},
+				Value:   "text",
+			},
+			&cli.DurationFlag{
+				Name:    "timeout",
+				Usage:   "cancel the command after this duration (e.g. 30s, 5m), output retrieved so far is still written",
+				EnvVars: []string{"MSGRAPH_TIMEOUT"},
+			},
+			&cli.DurationFlag{
+				Name:    "request-timeout",
+				Usage:   "give up on a single Graph request, or retry of it, after this duration",
+				EnvVars: []string{"MSGRAPH_REQUEST_TIMEOUT"},
+			},
+			&cli.Float64Flag{
+				Name:    "rate",
+				Usage:   "limit Graph requests to this many per second, lowered automatically while throttled, no limit when 0",
+				EnvVars: []string{"MSGRAPH_RATE"},
+			},
+			&cli.IntFlag{
+				Name:    "burst",
+				Usage:   "number of requests --rate allows in a burst",
+				EnvVars: []string{"MSGRAPH_BURST"},
+				Value:   10,
+			},
+			&cli.IntFlag{
+				Name:    "concurrency",
+				Usage:   "number of requests sent at a time by commands making a request per object",
+				EnvVars: []string{"MSGRAPH_CONCURRENCY"},
+				Value:   msgraph.DefaultConcurrency,
+			},
 			&cli.StringFlag{
 				Name:     "output",
 				Aliases:  []string{"o"},
-				Usage:    fmt.Sprintf("output format: (%s)", []string{"json", "text", "table", "string"}),
+				Usage:    fmt.Sprintf("output format: (%s)", []string{"json", "text", "table", "string", "csv", "tsv", "yaml", "ndjson", "markdown", "html", "template=...", "template-file=...", "jsonpath=..."}),
 				Required: false,
 				Value:    "string",
 			},
+			&cli.StringFlag{
+				Name:    "separator",
+				Usage:   "separator joining list values into one csv/tsv column",
+				EnvVars: []string{"MSGRAPH_SEPARATOR"},
+				Value:   output.DefaultSeparator,
+			},
+			&cli.BoolFlag{
+				Name:  "wide",
+				Usage: "show every property in table output and do not truncate it to the terminal width",
+			},
+			&cli.StringFlag{
+				Name:  "title",
+				Usage: "title of html output",
+				Value: "Microsoft Graph report",
+			},
+			&cli.BoolFlag{
+				Name:  "collection",
+				Usage: "apply template and jsonpath output to the whole collection instead of each resource",
+			},
 			&cli.StringFlag{
 				Name:     "fields",
 				Aliases:  []string{"f"},
@@ -177,103 +536,43 @@ func main() {
 				Required: false,
 			},
 		},
-		Commands: []*cli.Command{
-			{
-				Name:        "groups",
-				Aliases:     []string{"g"},
-				Usage:       "The Azure Active Directory 'groups' resource",
-				Description: "Actions for the groups resource",
-				Subcommands: []*cli.Command{
-					{
-						Name:      "list",
-						Aliases:   []string{"l"},
-						Usage:     "list groups, optionally filtering by start string",
-						ArgsUsage: "[filter - group name start]",
-						Action: func(c *cli.Context) error {
-
-							if c.IsSet("verbose") {
-								level, err := log.ParseLevel(c.String("verbose"))
-								helpers.ErrorHandlerFatal("Could not parse verbosity ", err)
-								log.SetLevel(level)
-							}
-							list(
-								"groups",
-								c.String("tenant"),
-								c.String("clientID"),
-								c.String("clientSecret"),
-								*c,
-								c.Args(),
-							)
-							return nil
-						},
-					},
-				},
-			},
-			{
-				Name:        "users",
-				Aliases:     []string{"u"},
-				Usage:       "The Azure Active Directory 'users' resource",
-				Description: "Actions for the users resource",
-				Subcommands: []*cli.Command{
-					{
-						Name:      "list",
-						Aliases:   []string{"l"},
-						Usage:     "list users, optionally filtering by start string",
-						ArgsUsage: "[filter - users name start]",
-						Action: func(c *cli.Context) error {
-
-							if c.IsSet("verbose") {
-								level, err := log.ParseLevel(c.String("verbose"))
-								helpers.ErrorHandlerFatal("Could not parse verbosity ", err)
-								log.SetLevel(level)
-							}
-							list(
-								"users",
-								c.String("tenant"),
-								c.String("clientID"),
-								c.String("clientSecret"),
-								*c,
-								c.Args(),
-							)
-							return nil
-						},
-					},
-				},
-			},
-			{
-				Name:        "applications",
-				Aliases:     []string{"a"},
-				Usage:       "The Azure Active Directory 'applications' resource",
-				Description: "Actions for the applications resource",
-				Subcommands: []*cli.Command{
-					{
-						Name:      "list",
-						Aliases:   []string{"l"},
-						Usage:     "list applications, optionally filtering by start string",
-						ArgsUsage: "[filter - applications name start]",
-						Action: func(c *cli.Context) error {
-
-							if c.IsSet("verbose") {
-								level, err := log.ParseLevel(c.String("verbose"))
-								helpers.ErrorHandlerFatal("Could not parse verbosity ", err)
-								log.SetLevel(level)
-							}
-							list(
-								"applications",
-								c.String("tenant"),
-								c.String("clientID"),
-								c.String("clientSecret"),
-								*c,
-								c.Args(),
-							)
-							return nil
-						},
-					},
-				},
-			},
-		},
 	}
-	err := app.Run(os.Args)
+	app.Commands = append(resourceCommands(resourceSubcommands()), rawCommand())
+	app.Commands = append(app.Commands, loginCommands()...)
+	cancel := ctx.CancelFunc(func() {})
+	app.Before = func(c *cli.Context) error {
+		if c.Duration("timeout") > 0 {
+			c.Context, cancel = ctx.WithTimeout(c.Context, c.Duration("timeout"))
+		}
+		return setLogFormat(c)
+	}
+	app.After = func(c *cli.Context) error {
+		cancel()
+		return nil
+	}
+
+	return app
+}
+
+// cancelOnInterrupt returns a context cancelled by the first interrupt (Ctrl+C), so that in-flight requests
+// are abandoned and the output retrieved so far is written. A second interrupt exits immediately.
+func cancelOnInterrupt() ctx.Context {
+	interruptible, cancel := ctx.WithCancel(ctx.Background())
+
+	interrupts := make(chan os.Signal, 2)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		<-interrupts
+		log.Warn("Interrupted, cancelling requests. Interrupt again to exit immediately")
+		cancel()
+		<-interrupts
+		os.Exit(130)
+	}()
+	return interruptible
+}
+
+func main() {
+	err := newApp().RunContext(cancelOnInterrupt(), os.Args)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+	"github.com/urfave/cli/v2"
+	"westpac.co.nz/msgraph/pkg/graphtest"
+)
+
+// exitCode the code passed to os.Exit by a fatal log, recovered by run
+type exitCode int
+
+type CLITestSuite struct {
+	suite.Suite
+	server *graphtest.Server
+	logs   bytes.Buffer
+}
+
+func (suite *CLITestSuite) SetupTest() {
+	server, err := graphtest.NewServer(graphtest.DefaultFixtures())
+	suite.Require().NoError(err)
+	suite.server = server
+
+	suite.logs.Reset()
+	log.SetOutput(&suite.logs)
+	log.StandardLogger().ExitFunc = func(code int) { panic(exitCode(code)) }
+}
+
+func (suite *CLITestSuite) TearDownTest() {
+	suite.server.Close()
+	log.SetOutput(os.Stderr)
+	log.SetFormatter(&log.TextFormatter{})
+	log.StandardLogger().ExitFunc = os.Exit
+}
+
+// run runs the CLI against the fake Graph API and returns its output and exit code
+func (suite *CLITestSuite) run(args ...string) (output string, code int) {
+	var stdout bytes.Buffer
+	app := newApp()
+	app.Writer = &stdout
+	app.ErrWriter = ioutil.Discard
+	app.ExitErrHandler = func(*cli.Context, error) {}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			exit, ok := recovered.(exitCode)
+			if !ok {
+				panic(recovered)
+			}
+			output, code = stdout.String(), int(exit)
+		}
+	}()
+
+	err := app.Run(append([]string{
+		"msgraph",
+		"--graph-url", suite.server.URL,
+		"--authority-host", suite.server.URL,
+		"--tenant", "contoso",
+		"--clientID", "11111111-1111-1111-1111-111111111111",
+		"--clientSecret", "secret",
+		"--no-token-cache",
+	}, args...))
+	if err != nil {
+		return stdout.String(), 1
+	}
+	return stdout.String(), 0
+}
+
+func (suite *CLITestSuite) graphRequests() []string {
+	var requests []string
+	for _, request := range suite.server.Requests() {
+		if !strings.Contains(request, "/oauth2/") {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+func (suite *CLITestSuite) TestListUsersAllPages() {
+	output, code := suite.run("users", "list")
+
+	suite.Equal(0, code)
+	suite.Equal("Adele Vance\nAlex Wilber\nDiego Siciliani\nIsaiah Langer\nLee Gu\nMegan Bowen\n\n", output)
+	suite.Len(suite.graphRequests(), 3)
+}
+
+func (suite *CLITestSuite) TestListUsersStartWith() {
+	output, code := suite.run("users", "list", "A")
+
+	suite.Equal(0, code)
+	suite.Equal("Adele Vance\nAlex Wilber\n\n", output)
+	suite.Equal([]string{"GET /v1.0/users?%24filter=startswith%28displayName%2C%27A%27%29"}, suite.graphRequests())
+}
+
+func (suite *CLITestSuite) TestSelectFields() {
+	output, code := suite.run("-o", "csv", "--fields", "displayName,department,onPremisesExtensionAttributes.extensionAttribute1", "users", "list", "Lee")
+
+	suite.Equal(0, code)
+	suite.Equal("displayName,department,onPremisesExtensionAttributes.extensionAttribute1\nLee Gu,Manufacturing,CC-100\n", output)
+	suite.Contains(suite.graphRequests()[0], "%24select=id%2CdisplayName%2Cdepartment%2ConPremisesExtensionAttributes")
+}
+
+func (suite *CLITestSuite) TestUserProperties() {
+	output, code := suite.run("-o", "template={{.DisplayName}} {{.Department}} {{.AccountEnabled}}", "users", "list", "Adele")
+
+	suite.Equal(0, code)
+	suite.Equal("Adele Vance Retail true\n", output)
+}
+
+func (suite *CLITestSuite) TestGroupsTable() {
+	output, code := suite.run("-o", "table", "--fields", "displayName,securityEnabled", "groups", "list")
+
+	suite.Equal(0, code)
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+	suite.Equal([]string{
+		"displayName    securityEnabled",
+		"HR Taskforce   false",
+		"Sales Readers  true",
+		"Retail Admins  true",
+	}, lines)
+}
+
+func (suite *CLITestSuite) TestApplicationsJSONPath() {
+	output, code := suite.run("-o", "jsonpath={.displayName}: {.signInAudience}", "applications", "list", "Sales")
+
+	suite.Equal(0, code)
+	suite.Equal("Sales Mobile: AzureADMultipleOrgs\n", output)
+}
+
+func (suite *CLITestSuite) TestListWhere() {
+	output, code := suite.run("users", "list", "--where", "department=Retail", "A")
+
+	suite.Equal(0, code)
+	suite.Equal("Adele Vance\n\n", output)
+	suite.Equal([]string{"GET /v1.0/users?%24filter=startswith%28displayName%2C%27A%27%29+and+department+eq+%27Retail%27"}, suite.graphRequests())
+}
+
+func (suite *CLITestSuite) TestListWhereNotFilterable() {
+	_, code := suite.run("users", "list", "--where", "officeLocation=18/2111")
+
+	suite.Equal(1, code)
+	suite.Empty(suite.graphRequests())
+}
+
+func (suite *CLITestSuite) TestGet() {
+	output, code := suite.run("-o", "table", "users", "get", "4562bcc8-c436-4f95-b7c0-4f8ce89dca5e")
+
+	suite.Equal(0, code)
+	suite.Contains(output, "userPrincipalName", "the registered default columns")
+	suite.Contains(output, "AdeleV@contoso.com")
+	suite.NotContains(output, "department")
+}
+
+func (suite *CLITestSuite) TestIDOutsideCollection() {
+	for _, args := range [][]string{
+		{"users", "delete", "--yes", "../groups/02bd9fd6-8f93-4758-87c3-1fb73740a315"},
+		{"users", "get", ".."},
+		{"users", "get", "."},
+		{"groups", "get", `..\users`},
+	} {
+		_, code := suite.run(args...)
+
+		suite.Equal(1, code, args)
+	}
+	suite.Empty(suite.graphRequests())
+}
+
+func (suite *CLITestSuite) TestGetByUserPrincipalName() {
+	output, code := suite.run("-o", "csv", "--fields", "displayName", "users", "get", "megan_fabrikam.com#EXT#@contoso.onmicrosoft.com")
+
+	suite.Equal(0, code)
+	suite.Equal("displayName\nMegan Bowen\n", output)
+	suite.Equal([]string{"GET /v1.0/users/megan_fabrikam.com%23EXT%23@contoso.onmicrosoft.com?%24select=id%2CdisplayName"}, suite.graphRequests())
+}
+
+func (suite *CLITestSuite) TestDelete() {
+	_, code := suite.run("groups", "delete", "02bd9fd6-8f93-4758-87c3-1fb73740a315")
+	suite.Equal(1, code, "nothing is deleted without --yes")
+	suite.Empty(suite.graphRequests())
+
+	_, code = suite.run("groups", "delete", "--yes", "02bd9fd6-8f93-4758-87c3-1fb73740a315")
+	suite.Equal(0, code)
+
+	_, code = suite.run("groups", "get", "02bd9fd6-8f93-4758-87c3-1fb73740a315")
+	suite.Equal(1, code)
+	suite.Equal([]string{
+		"DELETE /v1.0/groups/02bd9fd6-8f93-4758-87c3-1fb73740a315",
+		"GET /v1.0/groups/02bd9fd6-8f93-4758-87c3-1fb73740a315",
+	}, suite.graphRequests())
+}
+
+func (suite *CLITestSuite) TestGuestPrincipalEscapedOnce() {
+	_, code := suite.run("users", "approles", "list", "megan_fabrikam.com#EXT#@contoso.onmicrosoft.com")
+
+	suite.Equal(0, code)
+	suite.Equal([]string{"GET /v1.0/users/megan_fabrikam.com%23EXT%23@contoso.onmicrosoft.com/appRoleAssignments"}, suite.graphRequests())
+}
+
+func (suite *CLITestSuite) TestApplicationPermissionsAdminConsent() {
+	output, code := suite.run("-o", "csv", "--fields", "value,type,granted", "applications", "permissions", "Retail Dashboard")
+
+	suite.Equal(0, code)
+	suite.Equal("value,type,granted\nUser.Read,Delegated,true\nMail.Send,Delegated,false\n", output,
+		"a user consenting for themselves does not grant the permission")
+}
+
+func (suite *CLITestSuite) TestGrantsAuditDeletedPrincipal() {
+	output, code := suite.run("-o", "csv", "--fields", "clientDisplayName,resourceDisplayName,consent,principalName,highRisk", "grants", "audit")
+
+	suite.Equal(0, code)
+	suite.Equal("clientDisplayName,resourceDisplayName,consent,principalName,highRisk\n"+
+		"Retail Dashboard,Microsoft Graph,admin,,false\n"+
+		"Retail Dashboard,Microsoft Graph,user,,true\n", output)
+}
+
+func (suite *CLITestSuite) TestThrottlingRetried() {
+	suite.server.Throttle(2)
+
+	output, code := suite.run("groups", "list", "HR")
+
+	suite.Equal(0, code)
+	suite.Equal("HR Taskforce\n\n", output)
+	suite.Len(suite.graphRequests(), 3)
+}
+
+func (suite *CLITestSuite) TestRateLimited() {
+	suite.server.Throttle(1)
+
+	output, code := suite.run("--rate", "50", "--burst", "1", "users", "list")
+
+	suite.Equal(0, code)
+	suite.Equal("Adele Vance\nAlex Wilber\nDiego Siciliani\nIsaiah Langer\nLee Gu\nMegan Bowen\n\n", output)
+	suite.Len(suite.graphRequests(), 4)
+}
+
+func (suite *CLITestSuite) TestGroupOwners() {
+	output, code := suite.run("--concurrency", "3", "-o", "csv", "--fields", "displayName,ownerCount,owners", "groups", "owners")
+
+	suite.Equal(0, code)
+	suite.Equal("displayName,ownerCount,owners\n"+
+		"HR Taskforce,1,Adele Vance\n"+
+		"Sales Readers,2,Alex Wilber;Lee Gu\n"+
+		"Retail Admins,0,\n", output)
+}
+
+func (suite *CLITestSuite) TestGroupOwnersKeepGoing() {
+	suite.server.Fail(http.MethodGet, "/groups/0a53828f-36c9-44c3-be3d-99a7fce977ac/owners", http.StatusForbidden, "Authorization_RequestDenied", "Insufficient privileges to complete the operation.")
+
+	output, code := suite.run("groups", "owners", "--keep-going")
+
+	suite.Equal(1, code)
+	suite.Equal("HR Taskforce: 1 owners\nRetail Admins: 0 owners\n\n", output)
+	suite.Contains(suite.logs.String(), "1 requests failed")
+}
+
+func (suite *CLITestSuite) TestRawAllPages() {
+	output, code := suite.run("raw", "--all", "--raw", "GET", "/v1.0/users?$select=displayName")
+
+	suite.Equal(0, code)
+	suite.Contains(output, `"value":[{"displayName":"Adele Vance","id":"4562bcc8-c436-4f95-b7c0-4f8ce89dca5e"},`)
+	suite.Contains(output, `"displayName":"Lee Gu"`)
+	suite.NotContains(output, "nextLink")
+	suite.Len(suite.graphRequests(), 3)
+}
+
+func (suite *CLITestSuite) TestRawPretty() {
+	output, code := suite.run("--api-version", "beta", "raw", "get", "/groups/02bd9fd6-8f93-4758-87c3-1fb73740a315?$select=displayName")
+
+	suite.Equal(0, code)
+	suite.Equal("{\n  \"displayName\": \"HR Taskforce\",\n  \"id\": \"02bd9fd6-8f93-4758-87c3-1fb73740a315\"\n}\n", output)
+	suite.Equal([]string{"GET /beta/groups/02bd9fd6-8f93-4758-87c3-1fb73740a315?$select=displayName"}, suite.graphRequests())
+}
+
+func (suite *CLITestSuite) TestRawBody() {
+	suite.server.Fail(http.MethodPost, "/groups", http.StatusBadRequest, "Request_BadRequest", "A value is required for property 'mailNickname' of resource 'Group'.")
+	body := filepath.Join(suite.T().TempDir(), "group.json")
+	suite.Require().NoError(ioutil.WriteFile(body, []byte(`{"displayName": "Finance"}`), 0600))
+
+	_, code := suite.run("raw", "--body", "@"+body, "POST", "/v1.0/groups")
+
+	suite.Equal(1, code)
+	suite.Contains(suite.logs.String(), "mailNickname")
+
+	_, code = suite.run("raw", "--body", "{displayName", "POST", "/v1.0/groups")
+	suite.Equal(1, code, "invalid JSON is not sent")
+	suite.Len(suite.graphRequests(), 1)
+}
+
+func (suite *CLITestSuite) TestGraphError() {
+	suite.server.Fail(http.MethodGet, "/groups", http.StatusForbidden, "Authorization_RequestDenied", "Insufficient privileges to complete the operation.")
+
+	_, code := suite.run("groups", "list")
+
+	suite.Equal(1, code)
+	suite.Contains(suite.logs.String(), "Authorization_RequestDenied")
+	suite.Contains(suite.logs.String(), "Insufficient privileges")
+}
+
+func (suite *CLITestSuite) TestTimeoutWritesPartialOutput() {
+	suite.server.Hang(1)
+
+	output, code := suite.run("--timeout", "300ms", "users", "list")
+
+	suite.Equal(1, code)
+	suite.Equal("Adele Vance\nAlex Wilber\n\n", output)
+	suite.Contains(suite.logs.String(), "context deadline exceeded")
+}
+
+func (suite *CLITestSuite) TestRequestTimeout() {
+	suite.server.Hang(0)
+
+	_, code := suite.run("--request-timeout", "100ms", "groups", "list")
+
+	suite.Equal(1, code)
+	suite.Contains(suite.logs.String(), "Listing failed")
+}
+
+func (suite *CLITestSuite) TestRecordReplay() {
+	directory := suite.T().TempDir()
+
+	recorded, code := suite.run("--record", directory, "-o", "csv", "--fields", "jobTitle,department", "users", "list")
+	suite.Equal(0, code)
+	suite.server.Close()
+
+	replayed, code := suite.run("--replay", directory, "-o", "csv", "--fields", "jobTitle,department", "users", "list")
+	suite.Equal(0, code)
+	suite.Equal(recorded, replayed)
+
+	fixture, err := ioutil.ReadFile(filepath.Join(directory, "0001-GET-v1.0-users.json"))
+	suite.Require().NoError(err)
+	suite.NotContains(string(fixture), graphtest.AccessToken)
+	suite.Contains(string(fixture), `"jobTitle": "Retail Manager"`)
+
+	_, code = suite.run("--replay", directory, "users", "list")
+	suite.Equal(1, code, "requests that were not recorded fail")
+}
+
+func (suite *CLITestSuite) TestRecordUsersAndGroups() {
+	directory := suite.T().TempDir()
+
+	_, code := suite.run("--record", directory, "-o", "json", "users", "list", "Adele")
+	suite.Equal(0, code)
+	_, code = suite.run("--record", directory, "-o", "json", "groups", "list")
+	suite.Equal(0, code)
+
+	files, _ := filepath.Glob(filepath.Join(directory, "*.json"))
+	suite.Require().Len(files, 3, "a page of users and two of groups")
+	var recorded string
+	for _, file := range files {
+		data, _ := ioutil.ReadFile(file)
+		recorded += string(data)
+	}
+	suite.NotContains(recorded, "Adele Vance", "the names of users are personal data")
+	suite.Contains(recorded, `"displayName": "Retail Admins"`, "the names of groups are not")
+}
+
+func (suite *CLITestSuite) TestTraceLogsRedacted() {
+	output, code := suite.run("-V", "trace", "--log-format", "json", "users", "list", "Lee")
+	log.SetLevel(log.ErrorLevel)
+
+	suite.Equal(0, code)
+	suite.Equal("Lee Gu\n\n", output, "logs are not written to the command output")
+	suite.Contains(suite.logs.String(), `"method":"GET"`)
+	suite.Contains(suite.logs.String(), `"status":200`)
+	suite.NotContains(suite.logs.String(), graphtest.AccessToken)
+	suite.Contains(suite.logs.String(), "/contoso/oauth2/v2.0/token")
+	suite.Contains(suite.logs.String(), "client_secret=REDACTED")
+}
+
+func TestCLITestSuite(t *testing.T) {
+	suite.Run(t, new(CLITestSuite))
+}
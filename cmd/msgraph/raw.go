@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"westpac.co.nz/msgraph/pkg/helpers"
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+// rawCommand the 'raw' command: a request to any Graph endpoint, for those without a resource type
+func rawCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "raw",
+		Usage:     "send a request to any Graph endpoint and print the JSON response",
+		ArgsUsage: fmt.Sprintf("<method: %s> <path, e.g. /v1.0/organization>", strings.Join(msgraph.RawMethods, "|")),
+		Description: "The path may start with the API version, otherwise --api-version is used, and may include a query.\n" +
+			"Authentication, retries, --rate and --record/--replay apply as for every other command.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "body",
+				Usage: "JSON request body, or @file to read it from a file (@- for stdin)",
+			},
+			&cli.BoolFlag{
+				Name:  "all",
+				Usage: "follow @odata.nextLink and merge the values of every page into one response",
+			},
+			&cli.BoolFlag{
+				Name:  "raw",
+				Usage: "print the response as received instead of indented",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			setVerbosity(c)
+			if c.NArg() < 2 {
+				return cli.Exit("missing method or path", 1)
+			}
+
+			var body interface{}
+			if c.IsSet("body") {
+				data := readBody(c.String("body"))
+				if !json.Valid(data) {
+					return cli.Exit("the request body is not valid JSON", 1)
+				}
+				body = json.RawMessage(data)
+			}
+
+			baseResource := newBaseResource(*c)
+			response, err := baseResource.RawRequest(c.Context, c.Args().Get(0), c.Args().Get(1), body, c.Bool("all"))
+			helpers.ErrorHandlerFatal("Request failed: ", err)
+
+			if len(response) == 0 {
+				return nil
+			}
+			var indented bytes.Buffer
+			if !c.Bool("raw") && json.Indent(&indented, response, "", "  ") == nil {
+				response = indented.Bytes()
+			}
+			fmt.Fprintln(c.App.Writer, strings.TrimRight(string(response), "\n"))
+			return nil
+		},
+	}
+}
+
+// readBody the --body value, or the content of the file it names with a leading @
+func readBody(value string) []byte {
+	if !strings.HasPrefix(value, "@") {
+		return []byte(value)
+	}
+	var data []byte
+	var err error
+	if value == "@-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(strings.TrimPrefix(value, "@"))
+	}
+	helpers.ErrorHandlerFatal("Could not read request body ", err)
+	return data
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"westpac.co.nz/msgraph/pkg/helpers"
+	"westpac.co.nz/msgraph/pkg/msgraph"
+	"westpac.co.nz/msgraph/pkg/resources"
+)
+
+// resourceCommands a command per registered resource with a sub command per verb it supports, followed by
+// the extra sub commands of the resource
+func resourceCommands(extras map[string][]*cli.Command) []*cli.Command {
+	var commands []*cli.Command
+	for _, registration := range msgraph.Registrations() {
+		command := &cli.Command{
+			Name:        registration.Name,
+			Aliases:     registration.Aliases,
+			Usage:       fmt.Sprintf("The Azure Active Directory '%s' resource", registration.Name),
+			Description: registration.Description,
+		}
+		if command.Description == "" {
+			command.Description = fmt.Sprintf("Actions for the %s resource", registration.Name)
+		}
+		for _, verb := range registration.Verbs {
+			command.Subcommands = append(command.Subcommands, verbCommand(registration, verb))
+		}
+		command.Subcommands = append(command.Subcommands, extras[registration.Name]...)
+		commands = append(commands, command)
+	}
+	return commands
+}
+
+// resourceSubcommands the sub commands of registered resources beyond their verbs, by resource name
+func resourceSubcommands() map[string][]*cli.Command {
+	return map[string][]*cli.Command{
+		"groups": {
+			{
+				Name:  "mine",
+				Usage: "list the groups the signed in user is a member of, requires login",
+				Action: func(c *cli.Context) error {
+					setVerbosity(c)
+					list(resources.MyGroupsResource{}, *c, c.Args(), nil)
+					return nil
+				},
+			},
+			{
+				Name:      "owners",
+				Aliases:   []string{"o"},
+				Usage:     "list groups with their owners, optionally filtering by start string",
+				ArgsUsage: "[filter - group name start]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "keep-going",
+						Usage: "list the other groups when the owners of a group cannot be looked up",
+					},
+				},
+				Action: groupOwners,
+			},
+			appRoleAssignmentsCommand("groups"),
+		},
+		"users": {
+			appRoleAssignmentsCommand("users"),
+		},
+		"applications": {
+			{
+				Name:      "permissions",
+				Aliases:   []string{"p"},
+				Usage:     "list the permissions the application requires, resolved to their names",
+				ArgsUsage: "<application id, appId or display name>",
+				Action:    applicationPermissions,
+			},
+		},
+		"servicePrincipals": {
+			appRoleAssignmentsCommand("servicePrincipals"),
+		},
+		"oauth2PermissionGrants": oauth2PermissionGrantsCommands(),
+	}
+}
+
+// verbCommand the sub command of a verb of the registered resource
+func verbCommand(registration msgraph.Registration, verb msgraph.Verb) *cli.Command {
+	switch verb {
+	case msgraph.VerbList:
+		return &cli.Command{
+			Name:      "list",
+			Aliases:   []string{"l"},
+			Usage:     fmt.Sprintf("list %s, optionally filtering by %s", registration.PluralName(), registration.ListArgument),
+			ArgsUsage: fmt.Sprintf("[filter - %s]", registration.ListArgument),
+			Flags: []cli.Flag{
+				&cli.StringSliceFlag{
+					Name:  "where",
+					Usage: fmt.Sprintf("only list those where property=value, of %s", registration.Filterable),
+				},
+			},
+			Action: func(c *cli.Context) error {
+				setVerbosity(c)
+				where, err := registration.Where(c.StringSlice("where"))
+				if err != nil {
+					return cli.Exit(err.Error(), 1)
+				}
+				list(registration.API, *c, c.Args(), where)
+				return nil
+			},
+		}
+	case msgraph.VerbGet:
+		return &cli.Command{
+			Name:      "get",
+			Usage:     fmt.Sprintf("get one of the %s", registration.PluralName()),
+			ArgsUsage: "<id>",
+			Action: func(c *cli.Context) error {
+				setVerbosity(c)
+				if c.NArg() < 1 {
+					return cli.Exit("missing id", 1)
+				}
+				if err := checkID(c.Args().Get(0)); err != nil {
+					return cli.Exit(err.Error(), 1)
+				}
+				get(registration.API, *c, c.Args().Get(0))
+				return nil
+			},
+		}
+	case msgraph.VerbDelete:
+		return &cli.Command{
+			Name:      "delete",
+			Usage:     fmt.Sprintf("delete one of the %s, requires --yes", registration.PluralName()),
+			ArgsUsage: "<id>",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "yes",
+					Usage: "confirm the deletion, nothing is deleted without it",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				setVerbosity(c)
+				if c.NArg() < 1 {
+					return cli.Exit("missing id", 1)
+				}
+				if err := checkID(c.Args().Get(0)); err != nil {
+					return cli.Exit(err.Error(), 1)
+				}
+				if !c.Bool("yes") {
+					return cli.Exit(fmt.Sprintf("refusing to delete %s %s without --yes", registration.Name, c.Args().Get(0)), 1)
+				}
+				baseResource := newBaseResource(*c)
+
+				err := baseResource.Delete(c.Context, objectPath(registration.API, *c, c.Args().Get(0)))
+				helpers.ErrorHandlerFatal("Deleting failed: ", err)
+				log.Infof("Deleted %s %s", registration.Name, c.Args().Get(0))
+				return nil
+			},
+		}
+	}
+	panic(fmt.Sprintf("resource %s has unknown verb %s", registration.Name, verb))
+}
+
+// get shows the resource with id
+func get(resourceAPI msgraph.ResourceAPI, context cli.Context, id string) {
+
+	var baseResource = newBaseResource(context)
+
+	var params url.Values
+	if context.IsSet("fields") {
+		params = url.Values{"$select": {msgraph.SelectQuery(resourceAPI, fieldNames(context))}}
+	}
+	body, err := baseResource.Request(context.Context, "GET", objectPath(resourceAPI, context, id), params, nil)
+	helpers.ErrorHandlerFatal("Getting failed: ", err)
+
+	display([]msgraph.Resource{resourceAPI.ConvertToResource(body)}, context)
+}
+
+// objectPath the path of the object with id, checked by checkID
+func objectPath(resourceAPI msgraph.ResourceAPI, context cli.Context, id string) string {
+	return resourceAPI.CreateRequestPath(context, context.Args()) + "/" + id
+}
+
+// checkID refuses ids that are not a single path segment, such as ../groups/<id>, which would address an
+// object outside the collection of the command
+func checkID(id string) error {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, `/\`) {
+		return fmt.Errorf("invalid id '%s'", id)
+	}
+	return nil
+}
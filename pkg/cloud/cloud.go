@@ -0,0 +1,73 @@
+package cloud
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Cloud the login (authority) host and Graph API endpoint of an Azure cloud
+type Cloud struct {
+	Name          string
+	AuthorityHost string
+	GraphURL      string
+}
+
+var (
+	// Public the global Azure cloud
+	Public = Cloud{Name: "public", AuthorityHost: "https://login.microsoftonline.com/", GraphURL: "https://graph.microsoft.com/"}
+	// USGov Azure US Government (GCC High)
+	USGov = Cloud{Name: "usgov", AuthorityHost: "https://login.microsoftonline.us/", GraphURL: "https://graph.microsoft.us/"}
+	// USGovDoD Azure US Government Department of Defense
+	USGovDoD = Cloud{Name: "usgov-dod", AuthorityHost: "https://login.microsoftonline.us/", GraphURL: "https://dod-graph.microsoft.us/"}
+	// China Azure China operated by 21Vianet
+	China = Cloud{Name: "china", AuthorityHost: "https://login.chinacloudapi.cn/", GraphURL: "https://microsoftgraph.chinacloudapi.cn/"}
+)
+
+// Clouds the known clouds
+var Clouds = []Cloud{Public, USGov, USGovDoD, China}
+
+// Names the names of the known clouds
+func Names() []string {
+	var names []string
+	for _, cloud := range Clouds {
+		names = append(names, cloud.Name)
+	}
+	return names
+}
+
+// Lookup returns the cloud called name
+func Lookup(name string) (Cloud, error) {
+	for _, cloud := range Clouds {
+		if strings.EqualFold(cloud.Name, name) {
+			return cloud, nil
+		}
+	}
+	return Cloud{}, fmt.Errorf("unknown cloud '%s', expected one of %s", name, Names())
+}
+
+// WithOverrides returns the cloud with a custom Graph URL and/or authority host, empty values keep the cloud's own
+func (c Cloud) WithOverrides(graphURL string, authorityHost string) Cloud {
+	if graphURL != "" {
+		c.Name = "custom"
+		c.GraphURL = withTrailingSlash(graphURL)
+	}
+	if authorityHost != "" {
+		c.Name = "custom"
+		c.AuthorityHost = withTrailingSlash(authorityHost)
+	}
+	return c
+}
+
+// Scopes the client credential scopes of the cloud's Graph API
+func (c Cloud) Scopes() []string {
+	return []string{c.GraphURL + ".default"}
+}
+
+// DelegatedScopes the scopes requested when a user signs in, offline_access returns a refresh token
+func (c Cloud) DelegatedScopes() []string {
+	return []string{c.GraphURL + ".default", "offline_access", "openid", "profile"}
+}
+
+func withTrailingSlash(url string) string {
+	return strings.TrimSuffix(url, "/") + "/"
+}
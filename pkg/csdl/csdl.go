@@ -0,0 +1,170 @@
+// Package csdl reads the OData CSDL XML the Graph API describes itself with at /$metadata, and generates Go
+// types and msgraph.ResourceAPI implementations from it.
+package csdl
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Metadata the schemas of a $metadata document
+type Metadata struct {
+	Schemas []Schema
+}
+
+// Schema the types of one namespace, e.g. microsoft.graph
+type Schema struct {
+	Namespace        string            `xml:"Namespace,attr"`
+	Alias            string            `xml:"Alias,attr"`
+	EntityTypes      []StructuredType  `xml:"EntityType"`
+	ComplexTypes     []StructuredType  `xml:"ComplexType"`
+	EnumTypes        []EnumType        `xml:"EnumType"`
+	EntityContainers []EntityContainer `xml:"EntityContainer"`
+}
+
+// StructuredType an entity or complex type
+type StructuredType struct {
+	Name                 string               `xml:"Name,attr"`
+	BaseType             string               `xml:"BaseType,attr"`
+	Abstract             bool                 `xml:"Abstract,attr"`
+	OpenType             bool                 `xml:"OpenType,attr"`
+	Properties           []Property           `xml:"Property"`
+	NavigationProperties []NavigationProperty `xml:"NavigationProperty"`
+}
+
+// Property a structural property. Type is a primitive (Edm.String), an enum or complex type, or a
+// Collection() of one of those.
+type Property struct {
+	Name     string `xml:"Name,attr"`
+	Type     string `xml:"Type,attr"`
+	Nullable string `xml:"Nullable,attr"`
+}
+
+// IsNullable reports whether the property may be null, which is the default
+func (p Property) IsNullable() bool {
+	return p.Nullable != "false"
+}
+
+// NavigationProperty a relationship to other entities, only returned when expanded or requested by path
+type NavigationProperty struct {
+	Name           string `xml:"Name,attr"`
+	Type           string `xml:"Type,attr"`
+	ContainsTarget bool   `xml:"ContainsTarget,attr"`
+}
+
+// EnumType an enumeration, serialized in JSON as the member name
+type EnumType struct {
+	Name    string `xml:"Name,attr"`
+	IsFlags bool   `xml:"IsFlags,attr"`
+	Members []struct {
+		Name  string `xml:"Name,attr"`
+		Value string `xml:"Value,attr"`
+	} `xml:"Member"`
+}
+
+// EntityContainer the entity sets and singletons served by the API
+type EntityContainer struct {
+	Name       string      `xml:"Name,attr"`
+	EntitySets []EntitySet `xml:"EntitySet"`
+}
+
+// EntitySet a collection of entities served at /{name}
+type EntitySet struct {
+	Name       string `xml:"Name,attr"`
+	EntityType string `xml:"EntityType,attr"`
+}
+
+// Parse reads a $metadata document
+func Parse(r io.Reader) (*Metadata, error) {
+	var edmx struct {
+		XMLName      xml.Name `xml:"Edmx"`
+		DataServices struct {
+			Schemas []Schema `xml:"Schema"`
+		} `xml:"DataServices"`
+	}
+	if err := xml.NewDecoder(r).Decode(&edmx); err != nil {
+		return nil, fmt.Errorf("invalid CSDL: %v", err)
+	}
+	if len(edmx.DataServices.Schemas) == 0 {
+		return nil, fmt.Errorf("invalid CSDL: no schema")
+	}
+	return &Metadata{Schemas: edmx.DataServices.Schemas}, nil
+}
+
+// EntitySet the entity set with name
+func (m *Metadata) EntitySet(name string) (EntitySet, bool) {
+	for _, schema := range m.Schemas {
+		for _, container := range schema.EntityContainers {
+			for _, set := range container.EntitySets {
+				if set.Name == name {
+					set.EntityType = m.qualify(set.EntityType)
+					return set, true
+				}
+			}
+		}
+	}
+	return EntitySet{}, false
+}
+
+// kind of a named type
+type kind int
+
+const (
+	unknownKind kind = iota
+	entityKind
+	complexKind
+	enumKind
+)
+
+// lookup the type with the qualified name, e.g. microsoft.graph.user
+func (m *Metadata) lookup(name string) (kind, *StructuredType, *EnumType) {
+	name = m.qualify(name)
+	for _, schema := range m.Schemas {
+		if !strings.HasPrefix(name, schema.Namespace+".") {
+			continue
+		}
+		local := strings.TrimPrefix(name, schema.Namespace+".")
+		for index := range schema.EntityTypes {
+			if schema.EntityTypes[index].Name == local {
+				return entityKind, &schema.EntityTypes[index], nil
+			}
+		}
+		for index := range schema.ComplexTypes {
+			if schema.ComplexTypes[index].Name == local {
+				return complexKind, &schema.ComplexTypes[index], nil
+			}
+		}
+		for index := range schema.EnumTypes {
+			if schema.EnumTypes[index].Name == local {
+				return enumKind, nil, &schema.EnumTypes[index]
+			}
+		}
+	}
+	return unknownKind, nil, nil
+}
+
+// qualify replaces a schema alias prefix of name with the namespace, graph.user becomes microsoft.graph.user
+func (m *Metadata) qualify(name string) string {
+	for _, schema := range m.Schemas {
+		if schema.Alias != "" && strings.HasPrefix(name, schema.Alias+".") {
+			return schema.Namespace + strings.TrimPrefix(name, schema.Alias)
+		}
+	}
+	return name
+}
+
+// element the qualified type of the elements of a Collection() type, and whether it is one
+func (m *Metadata) element(typeName string) (string, bool) {
+	element, collection := elementType(typeName)
+	return m.qualify(element), collection
+}
+
+// elementType the type of the elements of a Collection() type, and whether it is one
+func elementType(typeName string) (string, bool) {
+	if strings.HasPrefix(typeName, "Collection(") && strings.HasSuffix(typeName, ")") {
+		return strings.TrimSuffix(strings.TrimPrefix(typeName, "Collection("), ")"), true
+	}
+	return typeName, false
+}
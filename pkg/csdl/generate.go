@@ -0,0 +1,338 @@
+package csdl
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Config what Generate emits
+type Config struct {
+	// Package the name of the generated package
+	Package string
+	// Source the metadata file name, mentioned in the header of the generated file
+	Source string
+	// EntitySets the entity sets to emit the types of: their entity types with their base types and the
+	// complex and enum types of their properties
+	EntitySets []string
+	// Resources also emit a list type, the Resource methods and a ResourceAPI per entity set
+	Resources bool
+}
+
+// primitiveTypes the Go type of the Edm primitive types
+var primitiveTypes = map[string]string{
+	"Edm.String":         "string",
+	"Edm.Boolean":        "bool",
+	"Edm.Byte":           "uint8",
+	"Edm.SByte":          "int8",
+	"Edm.Int16":          "int16",
+	"Edm.Int32":          "int32",
+	"Edm.Int64":          "int64",
+	"Edm.Single":         "float32",
+	"Edm.Double":         "float64",
+	"Edm.Decimal":        "float64",
+	"Edm.Guid":           "string",
+	"Edm.Date":           "string",
+	"Edm.TimeOfDay":      "string",
+	"Edm.Duration":       "string",
+	"Edm.DateTimeOffset": "time.Time",
+	"Edm.Binary":         "[]byte",
+}
+
+type generator struct {
+	metadata *Metadata
+	config   Config
+	// emitted the qualified names of the types to emit
+	emitted map[string]bool
+	imports map[string]bool
+	out     bytes.Buffer
+}
+
+// Generate the gofmt'ed source of the types of the configured entity sets, and their ResourceAPIs when
+// configured.
+// Nullable properties are pointers, collections slices and enums string types with a constant per member.
+// Navigation properties are typed when their target type is emitted and json.RawMessage otherwise.
+func Generate(metadata *Metadata, config Config) ([]byte, error) {
+	g := &generator{metadata: metadata, config: config, emitted: map[string]bool{}, imports: map[string]bool{}}
+
+	var sets []EntitySet
+	for _, name := range config.EntitySets {
+		set, found := metadata.EntitySet(name)
+		if !found {
+			return nil, fmt.Errorf("no entity set %s", name)
+		}
+		sets = append(sets, set)
+		if err := g.include(set.EntityType); err != nil {
+			return nil, err
+		}
+	}
+
+	var names []string
+	for name := range g.emitted {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return goName(localName(names[i])) < goName(localName(names[j])) })
+
+	for _, name := range names {
+		kind, structured, enum := metadata.lookup(name)
+		if kind == enumKind {
+			g.enum(name, enum)
+		} else {
+			g.structured(name, structured)
+		}
+	}
+	if config.Resources {
+		for _, set := range sets {
+			g.resource(set)
+		}
+	}
+
+	var source bytes.Buffer
+	fmt.Fprintf(&source, "// Code generated by msgraph-gen from %s. DO NOT EDIT.\n\npackage %s\n\n", config.Source, config.Package)
+	// standard library imports first, then the others, as goimports groups them
+	var standard, others []string
+	for path := range g.imports {
+		if strings.Contains(strings.SplitN(path, "/", 2)[0], ".") {
+			others = append(others, path)
+		} else {
+			standard = append(standard, path)
+		}
+	}
+	sort.Strings(standard)
+	sort.Strings(others)
+	source.WriteString("import (\n")
+	for _, path := range standard {
+		fmt.Fprintf(&source, "%q\n", path)
+	}
+	if len(standard) > 0 && len(others) > 0 {
+		source.WriteString("\n")
+	}
+	for _, path := range others {
+		fmt.Fprintf(&source, "%q\n", path)
+	}
+	source.WriteString(")\n")
+	source.Write(g.out.Bytes())
+
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated code does not compile: %v", err)
+	}
+	return formatted, nil
+}
+
+// include marks the type name and the types it depends on for emitting
+func (g *generator) include(name string) error {
+	name, _ = g.metadata.element(name)
+	if _, primitive := primitiveTypes[name]; primitive || strings.HasPrefix(name, "Edm.") || g.emitted[name] {
+		return nil
+	}
+	kind, structured, _ := g.metadata.lookup(name)
+	switch kind {
+	case unknownKind:
+		return fmt.Errorf("unknown type %s", name)
+	case enumKind:
+		g.emitted[name] = true
+		return nil
+	}
+
+	g.emitted[name] = true
+	if structured.BaseType != "" {
+		if err := g.include(structured.BaseType); err != nil {
+			return err
+		}
+	}
+	for _, property := range structured.Properties {
+		if err := g.include(property.Type); err != nil {
+			return fmt.Errorf("%s.%s: %v", name, property.Name, err)
+		}
+	}
+	return nil
+}
+
+func (g *generator) enum(name string, enum *EnumType) {
+	typeName := goName(enum.Name)
+	fmt.Fprintf(&g.out, "\n// %s the %s enumeration\ntype %s string\n\n", typeName, name, typeName)
+	if enum.IsFlags {
+		fmt.Fprintf(&g.out, "// %s values combine members, separated by commas\n", typeName)
+	}
+	g.out.WriteString("const (\n")
+	for _, member := range enum.Members {
+		fmt.Fprintf(&g.out, "%s%s %s = %q\n", typeName, goName(member.Name), typeName, member.Name)
+	}
+	g.out.WriteString(")\n")
+}
+
+func (g *generator) structured(name string, structured *StructuredType) {
+	typeName := goName(structured.Name)
+	fmt.Fprintf(&g.out, "\n// %s the %s type\ntype %s struct {\n", typeName, name, typeName)
+	if structured.BaseType != "" {
+		fmt.Fprintf(&g.out, "%s\n\n", goName(localName(g.metadata.qualify(structured.BaseType))))
+	}
+	for _, property := range structured.Properties {
+		if property.Type == "Edm.Stream" {
+			continue
+		}
+		fmt.Fprintf(&g.out, "%s %s `json:\"%s,omitempty\"`\n", goName(property.Name), g.goType(property.Type, property.IsNullable()), property.Name)
+	}
+	if len(structured.NavigationProperties) > 0 {
+		g.out.WriteString("\n// navigation properties, only set when expanded\n")
+	}
+	for _, navigation := range structured.NavigationProperties {
+		fmt.Fprintf(&g.out, "%s %s `json:\"%s,omitempty\"`\n", goName(navigation.Name), g.navigationType(navigation.Type), navigation.Name)
+	}
+	g.out.WriteString("}\n")
+}
+
+// goType the Go type of a structural property
+func (g *generator) goType(typeName string, nullable bool) string {
+	element, collection := g.metadata.element(typeName)
+
+	var goType string
+	if primitive, found := primitiveTypes[element]; found {
+		goType = primitive
+	} else if g.emitted[element] {
+		goType = goName(localName(element))
+	} else {
+		g.imports["encoding/json"] = true
+		return "json.RawMessage"
+	}
+	if goType == "time.Time" {
+		g.imports["time"] = true
+	}
+
+	if collection {
+		return "[]" + goType
+	}
+	if nullable && goType != "[]byte" {
+		return "*" + goType
+	}
+	return goType
+}
+
+// navigationType the Go type of a navigation property, json.RawMessage when its target is not emitted
+func (g *generator) navigationType(typeName string) string {
+	element, collection := g.metadata.element(typeName)
+	if !g.emitted[element] {
+		g.imports["encoding/json"] = true
+		return "json.RawMessage"
+	}
+	if collection {
+		return "[]" + goName(localName(element))
+	}
+	return "*" + goName(localName(element))
+}
+
+// resource emits the list response, Resource methods and ResourceAPI of the entity set
+func (g *generator) resource(set EntitySet) {
+	for _, path := range []string{"encoding/json", "net/url", "github.com/urfave/cli/v2",
+		"westpac.co.nz/msgraph/pkg/helpers", "westpac.co.nz/msgraph/pkg/msgraph"} {
+		g.imports[path] = true
+	}
+	typeName := goName(localName(set.EntityType))
+	resourceName := goName(set.Name) + "Resource"
+	displayName := g.hasProperty(set.EntityType, "displayName")
+
+	fmt.Fprintf(&g.out, "\n// %sList a page of the %s entity set\ntype %sList struct {\nValue []%s `json:\"value\"`\n}\n", typeName, set.Name, typeName, typeName)
+
+	fmt.Fprintf(&g.out, "\nfunc (e %s) ToString() string {\n", typeName)
+	if displayName {
+		g.out.WriteString("if e.DisplayName != nil {\nreturn *e.DisplayName\n}\n")
+	}
+	g.out.WriteString("return e.ID\n}\n")
+
+	fmt.Fprintf(&g.out, `
+// %[1]s the %[2]s entity set
+type %[1]s struct{}
+
+func (r %[1]s) ConvertToResourceSlice(body []byte) []msgraph.Resource {
+	var list %[3]sList
+	err := json.Unmarshal(body, &list)
+	helpers.ErrorHandlerFatal("JSON unmarshalling of response body failed:", err)
+
+	var resources = make([]msgraph.Resource, len(list.Value))
+	for index, value := range list.Value {
+		resources[index] = value
+	}
+	return resources
+}
+
+func (r %[1]s) ConvertToResource(body []byte) msgraph.Resource {
+	var entity %[3]s
+	err := json.Unmarshal(body, &entity)
+	helpers.ErrorHandlerFatal("JSON unmarshalling of response body failed:", err)
+	return entity
+}
+
+func (r %[1]s) CreateRequestPath(context cli.Context, args cli.Args) string {
+	return "/%[2]s"
+}
+`, resourceName, set.Name, typeName)
+
+	if !displayName {
+		fmt.Fprintf(&g.out, "\nfunc (r %s) CreateQueryParams(context cli.Context, args cli.Args) url.Values {\nreturn nil\n}\n", resourceName)
+		return
+	}
+	fmt.Fprintf(&g.out, `
+// CreateQueryParams filters on the start of the display name given as first argument
+func (r %s) CreateQueryParams(context cli.Context, args cli.Args) url.Values {
+	if args.Len() == 0 || args.Get(0) == "" {
+		return nil
+	}
+	filter := new(msgraph.FilterCriteria)
+	return msgraph.CreateURLFilterParams(filter.StartWith("displayName", args.Get(0)))
+}
+`, resourceName)
+}
+
+// hasProperty reports whether the structured type or one of its base types has the property
+func (g *generator) hasProperty(typeName string, property string) bool {
+	for typeName != "" {
+		_, structured, _ := g.metadata.lookup(typeName)
+		if structured == nil {
+			return false
+		}
+		for _, candidate := range structured.Properties {
+			if candidate.Name == property {
+				return true
+			}
+		}
+		typeName = structured.BaseType
+	}
+	return false
+}
+
+// localName the name of a qualified type without its namespace
+func localName(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// goName the exported Go name of a CSDL name: appId becomes AppID, userPrincipalName UserPrincipalName
+func goName(name string) string {
+	runes := []rune(name)
+	if len(runes) == 0 {
+		return name
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+
+	var out []rune
+	for index := 0; index < len(runes); index++ {
+		atWord := index == 0 || unicode.IsLower(runes[index-1]) || unicode.IsDigit(runes[index-1])
+		if atWord && index+1 < len(runes) && runes[index] == 'I' && runes[index+1] == 'd' &&
+			(index+2 == len(runes) || !unicode.IsLower(runes[index+2])) {
+			out = append(out, 'I', 'D')
+			index++
+			continue
+		}
+		if runes[index] == '_' || runes[index] == '-' {
+			if index+1 < len(runes) {
+				runes[index+1] = unicode.ToUpper(runes[index+1])
+			}
+			continue
+		}
+		out = append(out, runes[index])
+	}
+	return string(out)
+}
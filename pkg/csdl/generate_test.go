@@ -0,0 +1,104 @@
+package csdl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMetadata = `<?xml version="1.0" encoding="utf-8"?>
+<edmx:Edmx Version="4.0" xmlns:edmx="http://docs.oasis-open.org/odata/ns/edmx">
+  <edmx:DataServices>
+    <Schema Namespace="microsoft.graph" Alias="graph" xmlns="http://docs.oasis-open.org/odata/ns/edm">
+      <EnumType Name="riskLevel">
+        <Member Name="low" Value="0" />
+        <Member Name="high" Value="1" />
+      </EnumType>
+      <EntityType Name="entity" Abstract="true">
+        <Property Name="id" Type="Edm.String" Nullable="false" />
+      </EntityType>
+      <EntityType Name="device" BaseType="graph.entity">
+        <Property Name="deviceId" Type="Edm.String" />
+        <Property Name="accountEnabled" Type="Edm.Boolean" Nullable="false" />
+        <Property Name="approximateLastSignInDateTime" Type="Edm.DateTimeOffset" />
+        <Property Name="alternativeSecurityIds" Type="Collection(graph.alternativeSecurityId)" Nullable="false" />
+        <Property Name="risk" Type="graph.riskLevel" />
+        <Property Name="thumbnail" Type="Edm.Stream" />
+        <NavigationProperty Name="registeredOwners" Type="Collection(graph.entity)" />
+        <NavigationProperty Name="extensions" Type="Collection(graph.extension)" ContainsTarget="true" />
+      </EntityType>
+      <EntityType Name="extension" BaseType="graph.entity" />
+      <ComplexType Name="alternativeSecurityId">
+        <Property Name="key" Type="Edm.Binary" />
+        <Property Name="type" Type="Edm.Int32" />
+      </ComplexType>
+      <EntityContainer Name="GraphService">
+        <EntitySet Name="devices" EntityType="microsoft.graph.device" />
+      </EntityContainer>
+    </Schema>
+  </edmx:DataServices>
+</edmx:Edmx>`
+
+func TestGenerate(t *testing.T) {
+	metadata, err := Parse(strings.NewReader(testMetadata))
+	require.NoError(t, err)
+
+	source, err := Generate(metadata, Config{Package: "models", Source: "test.xml", EntitySets: []string{"devices"}, Resources: true})
+	require.NoError(t, err)
+	code := string(source)
+
+	assert.True(t, strings.HasPrefix(code, "// Code generated by msgraph-gen from test.xml. DO NOT EDIT."))
+	assert.Contains(t, code, "import (\n\t\"encoding/json\"\n\t\"net/url\"\n\t\"time\"\n\n\t\"github.com/urfave/cli/v2\"\n",
+		"standard library imports are grouped before the others")
+	assert.Regexp(t, `type Device struct {\s+Entity\n`, code, "base types are embedded")
+	assert.Regexp(t, "DeviceID +\\*string +`json:\"deviceId,omitempty\"`", code, "nullable properties are pointers")
+	assert.Regexp(t, "AccountEnabled +bool ", code)
+	assert.Regexp(t, "ApproximateLastSignInDateTime +\\*time.Time ", code)
+	assert.Regexp(t, "AlternativeSecurityIds +\\[\\]AlternativeSecurityID ", code, "complex types of properties are emitted")
+	assert.Regexp(t, "Key +\\[\\]byte ", code)
+	assert.Regexp(t, "Risk +\\*RiskLevel ", code)
+	assert.Contains(t, code, `RiskLevelHigh RiskLevel = "high"`)
+	assert.NotContains(t, code, "Thumbnail", "streams are not returned in JSON")
+	assert.Regexp(t, "RegisteredOwners +\\[\\]Entity ", code)
+	assert.Regexp(t, "Extensions +json.RawMessage ", code, "navigation targets are not emitted")
+	assert.Contains(t, code, "type DevicesResource struct{}")
+	assert.Contains(t, code, "func (e Device) ToString() string {\n\treturn e.ID\n}")
+	assert.Contains(t, code, "func (r DevicesResource) CreateQueryParams(context cli.Context, args cli.Args) url.Values {\n\treturn nil\n}")
+}
+
+func TestGenerateTypesOnly(t *testing.T) {
+	metadata, err := Parse(strings.NewReader(testMetadata))
+	require.NoError(t, err)
+
+	source, err := Generate(metadata, Config{Package: "models", Source: "test.xml", EntitySets: []string{"devices"}})
+	require.NoError(t, err)
+	code := string(source)
+
+	assert.Contains(t, code, "type Device struct {")
+	assert.NotContains(t, code, "DevicesResource")
+	assert.NotContains(t, code, "westpac.co.nz/msgraph/pkg/msgraph")
+}
+
+func TestGenerateUnknownEntitySet(t *testing.T) {
+	metadata, err := Parse(strings.NewReader(testMetadata))
+	require.NoError(t, err)
+
+	_, err = Generate(metadata, Config{Package: "models", EntitySets: []string{"users"}})
+
+	assert.EqualError(t, err, "no entity set users")
+}
+
+func TestGoName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"appId":                     "AppID",
+		"id":                        "ID",
+		"identifierUris":            "IdentifierUris",
+		"onPremisesImmutableId":     "OnPremisesImmutableID",
+		"userPrincipalName":         "UserPrincipalName",
+		"extension_a1b2_costCenter": "ExtensionA1b2CostCenter",
+	} {
+		assert.Equal(t, expected, goName(name))
+	}
+}
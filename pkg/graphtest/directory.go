@@ -0,0 +1,439 @@
+package graphtest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+// Directory an in-memory fake of the users, groups and applications of a tenant for unit tests of code using
+// the msgraph service interfaces, without HTTP. Like the Graph API it enforces unique user principal names,
+// keeps group memberships, soft deletes objects and evaluates msgraph.Criteria filters. Failures are
+// returned as *msgraph.GraphAPIError with the status and error code Graph would return.
+// A Directory is safe for concurrent use.
+type Directory struct {
+	mutex   sync.Mutex
+	objects map[string]*directoryObject
+	order   []string
+	members map[string][]string
+}
+
+type directoryObject struct {
+	collection string
+	properties map[string]interface{}
+	deleted    bool
+}
+
+// odataTypes the OData type of the objects of each collection
+var odataTypes = map[string]string{
+	"users":        "#microsoft.graph.user",
+	"groups":       "#microsoft.graph.group",
+	"applications": "#microsoft.graph.application",
+}
+
+// NewDirectory an empty directory
+func NewDirectory() *Directory {
+	return &Directory{
+		objects: map[string]*directoryObject{},
+		members: map[string][]string{},
+	}
+}
+
+// Users the user operations on the directory
+func (d *Directory) Users() msgraph.UserService {
+	return fakeUsers{fakeObjects{d, "users"}}
+}
+
+// Groups the group and membership operations on the directory
+func (d *Directory) Groups() msgraph.GroupService {
+	return fakeGroups{fakeObjects{d, "groups"}}
+}
+
+// Applications the application operations on the directory
+func (d *Directory) Applications() msgraph.ApplicationService {
+	return fakeObjects{d, "applications"}
+}
+
+// Deleted reports whether the object with id was deleted and can still be restored
+func (d *Directory) Deleted(id string) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	object, found := d.objects[id]
+	return found && object.deleted
+}
+
+// Restore restores the deleted object with id, as POST /directory/deletedItems/{id}/restore does.
+// Its group memberships are restored with it.
+func (d *Directory) Restore(id string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	object, found := d.objects[id]
+	if !found || !object.deleted {
+		return notFound(id)
+	}
+	if upn, ok := object.properties["userPrincipalName"].(string); ok && d.upnTaken(upn, id) {
+		return duplicateUPN()
+	}
+	object.deleted = false
+	delete(object.properties, "deletedDateTime")
+	return nil
+}
+
+// fakeObjects the operations common to every collection of the directory
+type fakeObjects struct {
+	directory  *Directory
+	collection string
+}
+
+func (f fakeObjects) List(ctx context.Context, criteria *msgraph.Criteria, v interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	d := f.directory
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var objects []map[string]interface{}
+	for _, id := range d.order {
+		object := d.objects[id]
+		if object.collection != f.collection || object.deleted {
+			continue
+		}
+		if criteria == nil || msgraph.Matches(criteria, object.properties) {
+			objects = append(objects, object.properties)
+		}
+	}
+	return appendObjects(v, objects)
+}
+
+func (f fakeObjects) Get(ctx context.Context, id string, v interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	d := f.directory
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	object, err := d.find(f.collection, id)
+	if err != nil {
+		return err
+	}
+	return decodeObject(object.properties, v)
+}
+
+func (f fakeObjects) Create(ctx context.Context, body interface{}, v interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	properties, err := objectProperties(body)
+	if err != nil {
+		return err
+	}
+	if name, _ := properties["displayName"].(string); name == "" {
+		return invalidProperty("displayName", f.collection)
+	}
+
+	d := f.directory
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if f.collection == "users" {
+		upn, _ := properties["userPrincipalName"].(string)
+		if upn == "" {
+			return invalidProperty("userPrincipalName", f.collection)
+		}
+		if d.upnTaken(upn, "") {
+			return duplicateUPN()
+		}
+	}
+	if f.collection == "applications" {
+		properties["appId"] = newID()
+	}
+	id := newID()
+	properties["id"] = id
+	properties["createdDateTime"] = time.Now().UTC().Format(time.RFC3339)
+
+	d.objects[id] = &directoryObject{collection: f.collection, properties: properties}
+	d.order = append(d.order, id)
+	return decodeObject(properties, v)
+}
+
+func (f fakeObjects) Update(ctx context.Context, id string, body interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	properties, err := objectProperties(body)
+	if err != nil {
+		return err
+	}
+
+	d := f.directory
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	object, err := d.find(f.collection, id)
+	if err != nil {
+		return err
+	}
+	for _, readOnly := range []string{"id", "appId", "createdDateTime"} {
+		if _, found := properties[readOnly]; found {
+			return &msgraph.GraphAPIError{StatusCode: http.StatusBadRequest, GraphAPIErrorObject: msgraph.GraphAPIErrorObject{
+				Code:    "Request_BadRequest",
+				Message: fmt.Sprintf("Property '%s' is read-only and cannot be set.", readOnly),
+			}}
+		}
+	}
+	if upn, ok := properties["userPrincipalName"].(string); ok && d.upnTaken(upn, object.properties["id"].(string)) {
+		return duplicateUPN()
+	}
+
+	for name, value := range properties {
+		object.properties[name] = value
+	}
+	return nil
+}
+
+// Delete soft deletes the object: it disappears from the collection but can be restored with Directory.Restore
+func (f fakeObjects) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	d := f.directory
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	object, err := d.find(f.collection, id)
+	if err != nil {
+		return err
+	}
+	object.deleted = true
+	object.properties["deletedDateTime"] = time.Now().UTC().Format(time.RFC3339)
+	return nil
+}
+
+type fakeUsers struct {
+	fakeObjects
+}
+
+func (f fakeUsers) MemberOf(ctx context.Context, id string, v interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	d := f.directory
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	user, err := d.find("users", id)
+	if err != nil {
+		return err
+	}
+	userID := user.properties["id"].(string)
+
+	var groups []map[string]interface{}
+	for _, groupID := range d.order {
+		group := d.objects[groupID]
+		if group.collection != "groups" || group.deleted {
+			continue
+		}
+		for _, memberID := range d.members[groupID] {
+			if memberID == userID {
+				groups = append(groups, typedObject(group))
+			}
+		}
+	}
+	return appendObjects(v, groups)
+}
+
+type fakeGroups struct {
+	fakeObjects
+}
+
+func (f fakeGroups) ListMembers(ctx context.Context, id string, v interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	d := f.directory
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if _, err := d.find("groups", id); err != nil {
+		return err
+	}
+
+	var members []map[string]interface{}
+	for _, memberID := range d.members[id] {
+		if member := d.objects[memberID]; !member.deleted {
+			members = append(members, typedObject(member))
+		}
+	}
+	return appendObjects(v, members)
+}
+
+func (f fakeGroups) AddMember(ctx context.Context, id string, memberID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	d := f.directory
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if _, err := d.find("groups", id); err != nil {
+		return err
+	}
+	member, found := d.objects[memberID]
+	if !found || member.deleted || member.collection == "applications" || memberID == id {
+		return notFound(memberID)
+	}
+	for _, existing := range d.members[id] {
+		if existing == memberID {
+			return &msgraph.GraphAPIError{StatusCode: http.StatusBadRequest, GraphAPIErrorObject: msgraph.GraphAPIErrorObject{
+				Code:    "Request_BadRequest",
+				Message: "One or more added object references already exist for the following modified properties: 'members'.",
+			}}
+		}
+	}
+	d.members[id] = append(d.members[id], memberID)
+	return nil
+}
+
+func (f fakeGroups) RemoveMember(ctx context.Context, id string, memberID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	d := f.directory
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if _, err := d.find("groups", id); err != nil {
+		return err
+	}
+	members := d.members[id]
+	for index, existing := range members {
+		if existing == memberID {
+			d.members[id] = append(members[:index:index], members[index+1:]...)
+			return nil
+		}
+	}
+	return notFound(memberID)
+}
+
+// find the object of collection with id, or user principal name for users, that is not deleted
+func (d *Directory) find(collection string, id string) (*directoryObject, error) {
+	if object, found := d.objects[id]; found && object.collection == collection && !object.deleted {
+		return object, nil
+	}
+	if collection == "users" {
+		for _, object := range d.objects {
+			upn, _ := object.properties["userPrincipalName"].(string)
+			if object.collection == collection && !object.deleted && strings.EqualFold(upn, id) {
+				return object, nil
+			}
+		}
+	}
+	return nil, notFound(id)
+}
+
+// upnTaken reports whether a user other than the one with id except holds upn
+func (d *Directory) upnTaken(upn string, except string) bool {
+	for id, object := range d.objects {
+		existing, _ := object.properties["userPrincipalName"].(string)
+		if id != except && object.collection == "users" && !object.deleted && strings.EqualFold(existing, upn) {
+			return true
+		}
+	}
+	return false
+}
+
+// typedObject the properties of object with its @odata.type, as returned for the members of a group
+func typedObject(object *directoryObject) map[string]interface{} {
+	properties := map[string]interface{}{"@odata.type": odataTypes[object.collection]}
+	for name, value := range object.properties {
+		properties[name] = value
+	}
+	return properties
+}
+
+// objectProperties body as a JSON object
+func objectProperties(body interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("JSON encoding for request body failed: %v", err)
+	}
+	var properties map[string]interface{}
+	if err := json.Unmarshal(data, &properties); err != nil || properties == nil {
+		return nil, &msgraph.GraphAPIError{StatusCode: http.StatusBadRequest, GraphAPIErrorObject: msgraph.GraphAPIErrorObject{
+			Code:    "BadRequest",
+			Message: "The request body must be a JSON object.",
+		}}
+	}
+	return properties, nil
+}
+
+// decodeObject decodes a copy of properties into v, unless v is nil
+func decodeObject(properties map[string]interface{}, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(properties)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// appendObjects decodes objects and appends them to v, a pointer to a slice, like the pages of a list
+func appendObjects(v interface{}, objects []map[string]interface{}) error {
+	slice := reflect.ValueOf(v)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("list needs a pointer to a slice, not %T", v)
+	}
+	if objects == nil {
+		objects = []map[string]interface{}{}
+	}
+	page := reflect.New(slice.Elem().Type())
+	if err := decodeObject(map[string]interface{}{"value": objects}, &struct {
+		Value interface{} `json:"value"`
+	}{page.Interface()}); err != nil {
+		return err
+	}
+	slice.Elem().Set(reflect.AppendSlice(slice.Elem(), page.Elem()))
+	return nil
+}
+
+func newID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+}
+
+func notFound(id string) error {
+	return &msgraph.GraphAPIError{StatusCode: http.StatusNotFound, GraphAPIErrorObject: msgraph.GraphAPIErrorObject{
+		Code:    "Request_ResourceNotFound",
+		Message: fmt.Sprintf("Resource '%s' does not exist or one of its queried reference-property objects are not present.", id),
+	}}
+}
+
+func duplicateUPN() error {
+	return &msgraph.GraphAPIError{StatusCode: http.StatusBadRequest, GraphAPIErrorObject: msgraph.GraphAPIErrorObject{
+		Code:    "Request_BadRequest",
+		Message: "Another object with the same value for property userPrincipalName already exists.",
+	}}
+}
+
+func invalidProperty(property string, collection string) error {
+	return &msgraph.GraphAPIError{StatusCode: http.StatusBadRequest, GraphAPIErrorObject: msgraph.GraphAPIErrorObject{
+		Code:    "Request_BadRequest",
+		Message: fmt.Sprintf("Invalid value specified for property '%s' of resource '%s'.", property, strings.TrimSuffix(collection, "s")),
+	}}
+}
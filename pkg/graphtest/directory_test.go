@@ -0,0 +1,103 @@
+package graphtest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+type object struct {
+	ID                string `json:"id"`
+	DisplayName       string `json:"displayName"`
+	UserPrincipalName string `json:"userPrincipalName,omitempty"`
+	ODataType         string `json:"@odata.type,omitempty"`
+}
+
+func createUser(t *testing.T, users msgraph.UserService, name string, upn string) object {
+	var user object
+	require.NoError(t, users.Create(context.Background(), object{DisplayName: name, UserPrincipalName: upn}, &user))
+	return user
+}
+
+func assertGraphError(t *testing.T, err error, status int, code string) {
+	graphError, ok := err.(*msgraph.GraphAPIError)
+	require.True(t, ok, "error %v is a %T", err, err)
+	assert.Equal(t, status, graphError.StatusCode)
+	assert.Equal(t, code, graphError.Code)
+}
+
+func TestDirectoryUniqueUserPrincipalName(t *testing.T) {
+	users := NewDirectory().Users()
+	alex := createUser(t, users, "Alex Wilber", "AlexW@contoso.com")
+
+	err := users.Create(context.Background(), object{DisplayName: "Alex W", UserPrincipalName: "alexw@CONTOSO.com"}, nil)
+	assertGraphError(t, err, http.StatusBadRequest, "Request_BadRequest")
+
+	var found object
+	require.NoError(t, users.Get(context.Background(), "alexw@contoso.com", &found), "users are found by principal name")
+	assert.Equal(t, alex, found)
+}
+
+func TestDirectoryFilter(t *testing.T) {
+	users := NewDirectory().Users()
+	createUser(t, users, "Adele Vance", "AdeleV@contoso.com")
+	createUser(t, users, "Alex Wilber", "AlexW@contoso.com")
+	createUser(t, users, "Lee Gu", "LeeG@contoso.com")
+
+	var found []object
+	filter := new(msgraph.FilterCriteria)
+	criteria := filter.LogicAnd(filter.StartWith("displayName", "a"), filter.LogicNot(filter.Equals("userPrincipalName", "alexw@contoso.com")))
+	require.NoError(t, users.List(context.Background(), criteria, &found))
+
+	require.Len(t, found, 1)
+	assert.Equal(t, "Adele Vance", found[0].DisplayName)
+}
+
+func TestDirectoryMembership(t *testing.T) {
+	directory := NewDirectory()
+	users, groups := directory.Users(), directory.Groups()
+	lee := createUser(t, users, "Lee Gu", "LeeG@contoso.com")
+	var sales object
+	require.NoError(t, groups.Create(context.Background(), object{DisplayName: "Sales"}, &sales))
+
+	require.NoError(t, groups.AddMember(context.Background(), sales.ID, lee.ID))
+	assertGraphError(t, groups.AddMember(context.Background(), sales.ID, lee.ID), http.StatusBadRequest, "Request_BadRequest")
+
+	var members, memberOf []object
+	require.NoError(t, groups.ListMembers(context.Background(), sales.ID, &members))
+	require.NoError(t, users.MemberOf(context.Background(), lee.ID, &memberOf))
+	assert.Equal(t, []object{{ID: lee.ID, DisplayName: "Lee Gu", UserPrincipalName: "LeeG@contoso.com", ODataType: "#microsoft.graph.user"}}, members)
+	assert.Equal(t, []object{{ID: sales.ID, DisplayName: "Sales", ODataType: "#microsoft.graph.group"}}, memberOf)
+
+	require.NoError(t, groups.RemoveMember(context.Background(), sales.ID, lee.ID))
+	assertGraphError(t, groups.RemoveMember(context.Background(), sales.ID, lee.ID), http.StatusNotFound, "Request_ResourceNotFound")
+}
+
+func TestDirectorySoftDelete(t *testing.T) {
+	directory := NewDirectory()
+	users, groups := directory.Users(), directory.Groups()
+	lee := createUser(t, users, "Lee Gu", "LeeG@contoso.com")
+	var sales object
+	require.NoError(t, groups.Create(context.Background(), object{DisplayName: "Sales"}, &sales))
+	require.NoError(t, groups.AddMember(context.Background(), sales.ID, lee.ID))
+
+	require.NoError(t, users.Delete(context.Background(), lee.ID))
+
+	assert.True(t, directory.Deleted(lee.ID))
+	assertGraphError(t, users.Get(context.Background(), lee.ID, nil), http.StatusNotFound, "Request_ResourceNotFound")
+	var members []object
+	require.NoError(t, groups.ListMembers(context.Background(), sales.ID, &members))
+	assert.Empty(t, members)
+
+	require.NoError(t, directory.Restore(lee.ID))
+	require.NoError(t, groups.ListMembers(context.Background(), sales.ID, &members))
+	assert.Len(t, members, 1, "memberships are restored with the user")
+
+	require.NoError(t, users.Delete(context.Background(), lee.ID))
+	createUser(t, users, "Lee Gu", "LeeG@contoso.com")
+	assertGraphError(t, directory.Restore(lee.ID), http.StatusBadRequest, "Request_BadRequest")
+}
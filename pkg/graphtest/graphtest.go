@@ -0,0 +1,358 @@
+// Package graphtest provides a fake Microsoft Graph API and token endpoint for tests that must not reach
+// graph.microsoft.com. Collections are served from JSON fixture files with paging, $filter, $select,
+// deletes, throttling and error responses like the real API. Directory is an in-memory fake of the msgraph
+// service interfaces for unit tests that need no HTTP at all.
+package graphtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+const (
+	// AccessToken the bearer token issued by the token endpoint and required by the API
+	AccessToken = "graphtest-access-token"
+	// DefaultPageSize the number of objects per page unless the request asks for fewer with $top
+	DefaultPageSize = 2
+
+	linksFixture = "links.json"
+)
+
+// Server a fake Graph API. The token endpoint is served at /{tenant}/oauth2/v2.0/token, so the server URL
+// serves as both the Graph URL and the authority host.
+type Server struct {
+	*httptest.Server
+	// PageSize the maximum number of objects per page
+	PageSize int
+
+	mutex       sync.Mutex
+	collections map[string][]map[string]interface{}
+	throttled   int
+	hang        bool
+	hangAfter   int
+	failures    map[string]failure
+	links       map[string][]string
+	requests    []string
+}
+
+type failure struct {
+	status int
+	code   string
+	text   string
+}
+
+// DefaultFixtures the directory of the fixtures shipped with this package: users, groups, applications,
+// service principals and delegated permission grants
+func DefaultFixtures() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "testdata")
+}
+
+// NewServer starts a server serving the collections in the *.json files of directory fixtures, named after
+// the collection (users.json is served at /v1.0/users). A file holds a JSON array or a {"value": [...]} object.
+// The optional links.json maps navigation paths such as "groups/{id}/owners" to the ids of the objects they hold.
+func NewServer(fixtures string) (*Server, error) {
+	s := &Server{
+		PageSize:    DefaultPageSize,
+		collections: map[string][]map[string]interface{}{},
+		failures:    map[string]failure{},
+		links:       map[string][]string{},
+	}
+
+	files, err := filepath.Glob(filepath.Join(fixtures, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if filepath.Base(file) == linksFixture {
+			if err := loadLinks(file, s.links); err != nil {
+				return nil, fmt.Errorf("fixture %s: %v", file, err)
+			}
+			continue
+		}
+		objects, err := loadFixture(file)
+		if err != nil {
+			return nil, fmt.Errorf("fixture %s: %v", file, err)
+		}
+		s.collections[strings.TrimSuffix(filepath.Base(file), ".json")] = objects
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s, nil
+}
+
+func loadLinks(file string, links map[string][]string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &links)
+}
+
+func loadFixture(file string) ([]map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var objects []map[string]interface{}
+	if err := json.Unmarshal(data, &objects); err == nil {
+		return objects, nil
+	}
+	var page struct {
+		Value []map[string]interface{} `json:"value"`
+	}
+	err = json.Unmarshal(data, &page)
+	return page.Value, err
+}
+
+// Throttle answers the next count API requests with 429 Too Many Requests and a zero Retry-After
+func (s *Server) Throttle(count int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.throttled = count
+}
+
+// Hang answers the next count API requests and leaves the ones after them unanswered until the client
+// gives up on them
+func (s *Server) Hang(count int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.hang = true
+	s.hangAfter = count
+}
+
+// Fail answers every method request for path, relative to the API version (e.g. "/users"), with a Graph
+// error payload of status, code and message
+func (s *Server) Fail(method, path string, status int, code, message string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failures[method+" "+path] = failure{status, code, message}
+}
+
+// Requests the method and URL, relative to the server, of every request received
+func (s *Server) Requests() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.RequestURI())
+
+	if strings.HasSuffix(r.URL.Path, "/oauth2/v2.0/token") {
+		s.serveToken(w, r)
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+AccessToken {
+		writeError(w, http.StatusUnauthorized, "InvalidAuthenticationToken", "Access token is empty or invalid.")
+		return
+	}
+	if s.hang {
+		if s.hangAfter == 0 {
+			s.mutex.Unlock()
+			<-r.Context().Done()
+			s.mutex.Lock()
+			return
+		}
+		s.hangAfter--
+	}
+	if s.throttled > 0 {
+		s.throttled--
+		w.Header().Set("Retry-After", "0")
+		writeError(w, http.StatusTooManyRequests, "TooManyRequests", "Too many requests, retry after 0 seconds.")
+		return
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(segments) < 2 || (segments[0] != msgraph.VersionV1 && segments[0] != msgraph.VersionBeta) {
+		writeError(w, http.StatusBadRequest, "BadRequest", "Invalid version.")
+		return
+	}
+	path := "/" + strings.Join(segments[1:], "/")
+	if failure, found := s.failures[r.Method+" "+path]; found {
+		writeError(w, failure.status, failure.code, failure.text)
+		return
+	}
+	if r.Method == http.MethodDelete && len(segments) == 3 {
+		s.deleteObject(w, segments[1], segments[2])
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Request_BadRequest", "Specified HTTP method is not allowed for the request target.")
+		return
+	}
+
+	collection, found := s.collections[segments[1]]
+	if !found {
+		writeError(w, http.StatusBadRequest, "BadRequest", fmt.Sprintf("Resource not found for the segment '%s'.", segments[1]))
+		return
+	}
+
+	if len(segments) == 2 {
+		s.serveCollection(w, r, collection)
+		return
+	}
+	if len(segments) > 4 {
+		writeError(w, http.StatusBadRequest, "BadRequest", "Unsupported segment.")
+		return
+	}
+
+	object := findObject(collection, segments[2])
+	if object == nil {
+		writeError(w, http.StatusNotFound, "Request_ResourceNotFound",
+			fmt.Sprintf("Resource '%s' does not exist or one of its queried reference-property objects are not present.", segments[2]))
+		return
+	}
+	if len(segments) == 3 {
+		writeJSON(w, http.StatusOK, selectProperties(object, r.URL.Query().Get("$select")))
+		return
+	}
+
+	// navigation property such as /groups/{id}/owners, empty unless listed in links.json
+	var linked []map[string]interface{}
+	for _, id := range s.links[strings.Join(segments[1:], "/")] {
+		for name, objects := range s.collections {
+			if target := findObject(objects, id); target != nil {
+				typed := map[string]interface{}{"@odata.type": odataTypes[name]}
+				for key, value := range target {
+					typed[key] = value
+				}
+				linked = append(linked, typed)
+			}
+		}
+	}
+	s.serveCollection(w, r, linked)
+}
+
+// deleteObject removes the object with id from the collection, answering 204 No Content like Graph
+func (s *Server) deleteObject(w http.ResponseWriter, name, id string) {
+	for index, object := range s.collections[name] {
+		if object["id"] == id {
+			s.collections[name] = append(s.collections[name][:index:index], s.collections[name][index+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Request_ResourceNotFound",
+		fmt.Sprintf("Resource '%s' does not exist or one of its queried reference-property objects are not present.", id))
+}
+
+// findObject the object with id, or with the user principal name id like Graph resolves users
+func findObject(collection []map[string]interface{}, id string) map[string]interface{} {
+	for _, object := range collection {
+		if upn, ok := object["userPrincipalName"].(string); object["id"] == id || ok && strings.EqualFold(upn, id) {
+			return object
+		}
+	}
+	return nil
+}
+
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("client_id") == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"error":             "invalid_request",
+			"error_description": "AADSTS900144: The request body must contain the following parameter: 'client_id'.",
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": AccessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(time.Hour.Seconds()),
+	})
+}
+
+func (s *Server) serveCollection(w http.ResponseWriter, r *http.Request, collection []map[string]interface{}) {
+	query := r.URL.Query()
+
+	objects := collection
+	if filter := query.Get("$filter"); filter != "" {
+		criteria, err := msgraph.ParseFilter(filter)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "BadRequest", "Invalid filter clause: "+err.Error())
+			return
+		}
+		objects = nil
+		for _, object := range collection {
+			if msgraph.Matches(criteria, object) {
+				objects = append(objects, object)
+			}
+		}
+	}
+
+	pageSize := s.PageSize
+	if top, err := strconv.Atoi(query.Get("$top")); err == nil && top > 0 && top < pageSize {
+		pageSize = top
+	}
+	skip, _ := strconv.Atoi(query.Get("$skiptoken"))
+	if skip > len(objects) {
+		skip = len(objects)
+	}
+	end := skip + pageSize
+	if end > len(objects) {
+		end = len(objects)
+	}
+
+	var value = make([]map[string]interface{}, 0, end-skip)
+	for _, object := range objects[skip:end] {
+		value = append(value, selectProperties(object, query.Get("$select")))
+	}
+	page := map[string]interface{}{
+		"@odata.context": s.URL + "/" + strings.Split(strings.Trim(r.URL.Path, "/"), "/")[0] + "/$metadata#" + r.URL.Path,
+		"value":          value,
+	}
+	if end < len(objects) {
+		query.Set("$skiptoken", strconv.Itoa(end))
+		next := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+		page["@odata.nextLink"] = s.URL + next.String()
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// selectProperties the id and the properties of object named in the $select value selection, all of them when empty
+func selectProperties(object map[string]interface{}, selection string) map[string]interface{} {
+	if selection == "" {
+		return object
+	}
+	selected := map[string]interface{}{"id": object["id"]}
+	for _, name := range strings.Split(selection, ",") {
+		for key, value := range object {
+			if strings.EqualFold(key, strings.TrimSpace(name)) {
+				selected[key] = value
+			}
+		}
+	}
+	return selected
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, msgraph.GraphAPIErrorResponse{Error: msgraph.GraphAPIErrorObject{
+		Code:    code,
+		Message: message,
+		InnerError: msgraph.GraphAPIInnerErrorObject{
+			Date:      time.Now().UTC().Format("2006-01-02T15:04:05"),
+			RequestID: "00000000-0000-0000-0000-000000000000",
+		},
+	}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -1,6 +1,8 @@
 package helpers
 
 import (
+	"regexp"
+
 	log "github.com/sirupsen/logrus"
 )
 
@@ -17,3 +19,10 @@ func ErrorHandlerFatal(details string, err error) {
 		log.Fatal(details, err)
 	}
 }
+
+var guidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// IsGUID Reports whether value looks like an Azure AD object or application id
+func IsGUID(value string) bool {
+	return guidPattern.MatchString(value)
+}
@@ -0,0 +1,85 @@
+// Package httplog logs HTTP requests with credentials and configured body properties redacted
+package httplog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"westpac.co.nz/msgraph/pkg/redact"
+)
+
+// Transport an http.RoundTripper logging every request it sends: the method, URL, status, duration and
+// Graph request id at debug level, and the redacted headers and bodies at trace level
+type Transport struct {
+	// Next sends the requests, http.DefaultTransport when nil
+	Next http.RoundTripper
+	// Logger logs the requests, the standard logger when nil
+	Logger *log.Logger
+	// Redactor redacts the headers and bodies logged at trace level
+	Redactor redact.Redactor
+}
+
+// RoundTrip sends req with the next transport and logs it
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	logger := t.Logger
+	if logger == nil {
+		logger = log.StandardLogger()
+	}
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if !logger.IsLevelEnabled(log.DebugLevel) {
+		return next.RoundTrip(req)
+	}
+
+	entry := logger.WithFields(log.Fields{"method": req.Method, "url": req.URL.String()})
+	trace := logger.IsLevelEnabled(log.TraceLevel)
+	if trace {
+		fields := log.Fields{"request_headers": t.Redactor.Header(req.Header)}
+		if req.GetBody != nil {
+			if body, err := req.GetBody(); err == nil {
+				data, _ := ioutil.ReadAll(body)
+				fields["request_body"] = string(t.Redactor.Body(req.URL, data))
+			}
+		}
+		entry = entry.WithFields(fields)
+	}
+
+	start := time.Now()
+	resp, err := next.RoundTrip(req)
+	entry = entry.WithField("duration", time.Since(start).Round(time.Millisecond).String())
+	if err != nil {
+		entry.WithError(err).Debug("HTTP request failed")
+		return resp, err
+	}
+
+	entry = entry.WithFields(log.Fields{"status": resp.StatusCode, "request_id": requestID(resp)})
+	if trace {
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+		entry = entry.WithFields(log.Fields{
+			"response_headers": t.Redactor.Header(resp.Header),
+			"response_body":    string(t.Redactor.Body(req.URL, data)),
+		})
+	}
+	entry.Debug("HTTP request")
+	return resp, nil
+}
+
+// requestID the id Graph and Azure AD give a request, used by Microsoft support to trace it
+func requestID(resp *http.Response) string {
+	for _, header := range []string{"request-id", "client-request-id", "x-ms-request-id"} {
+		if id := resp.Header.Get(header); id != "" {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package httplog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"westpac.co.nz/msgraph/pkg/redact"
+)
+
+func newLogger(level log.Level) (*log.Logger, *bytes.Buffer) {
+	var logs bytes.Buffer
+	logger := log.New()
+	logger.SetOutput(&logs)
+	logger.SetFormatter(&log.JSONFormatter{})
+	logger.SetLevel(level)
+	return logger, &logs
+}
+
+func TestTransportRedacts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("request-id", "833ad94d-cf61-4b1e-ae40-4c947d4a1348")
+		w.Write([]byte(`{"access_token":"eyJ0eXAi","token_type":"Bearer","employeeId":"1001"}`))
+	}))
+	defer server.Close()
+
+	logger, logs := newLogger(log.TraceLevel)
+	client := &http.Client{Transport: &Transport{Logger: logger, Redactor: redact.New(redact.DefaultProperties)}}
+
+	request, _ := http.NewRequest("POST", server.URL+"/token", strings.NewReader(url.Values{"client_secret": {"s3cr3t"}}.Encode()))
+	request.Header.Set("Authorization", "Bearer eyJ0eXAi")
+	response, err := client.Do(request)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	for _, secret := range []string{"eyJ0eXAi", "s3cr3t", "1001"} {
+		assert.NotContains(t, logs.String(), secret)
+	}
+
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+	assert.Equal(t, "POST", entry["method"])
+	assert.Equal(t, server.URL+"/token", entry["url"])
+	assert.Equal(t, float64(200), entry["status"])
+	assert.Equal(t, "833ad94d-cf61-4b1e-ae40-4c947d4a1348", entry["request_id"])
+	assert.Contains(t, entry, "duration")
+	assert.Equal(t, "client_secret=REDACTED", strings.Trim(entry["request_body"].(string), `"`))
+}
+
+func TestTransportQuietAboveDebug(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	logger, logs := newLogger(log.InfoLevel)
+	client := &http.Client{Transport: &Transport{Logger: logger}}
+
+	_, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.Empty(t, logs.String())
+}
@@ -0,0 +1,159 @@
+// Code generated by msgraph-gen from metadata/v1.0.xml. DO NOT EDIT.
+
+package models
+
+import (
+	"time"
+)
+
+// Application the microsoft.graph.application type
+type Application struct {
+	DirectoryObject
+
+	AppID                           *string                          `json:"appId,omitempty"`
+	CreatedDateTime                 *time.Time                       `json:"createdDateTime,omitempty"`
+	Description                     *string                          `json:"description,omitempty"`
+	DisabledByMicrosoftStatus       *string                          `json:"disabledByMicrosoftStatus,omitempty"`
+	DisplayName                     *string                          `json:"displayName,omitempty"`
+	IdentifierUris                  []string                         `json:"identifierUris,omitempty"`
+	IsFallbackPublicClient          *bool                            `json:"isFallbackPublicClient,omitempty"`
+	NativeAuthenticationApisEnabled *NativeAuthenticationApisEnabled `json:"nativeAuthenticationApisEnabled,omitempty"`
+	Notes                           *string                          `json:"notes,omitempty"`
+	PublisherDomain                 *string                          `json:"publisherDomain,omitempty"`
+	RequiredResourceAccess          []RequiredResourceAccess         `json:"requiredResourceAccess,omitempty"`
+	SignInAudience                  *string                          `json:"signInAudience,omitempty"`
+	Tags                            []string                         `json:"tags,omitempty"`
+
+	// navigation properties, only set when expanded
+	CreatedOnBehalfOf *DirectoryObject  `json:"createdOnBehalfOf,omitempty"`
+	Owners            []DirectoryObject `json:"owners,omitempty"`
+}
+
+// AssignedLicense the microsoft.graph.assignedLicense type
+type AssignedLicense struct {
+	DisabledPlans []string `json:"disabledPlans,omitempty"`
+	SkuID         *string  `json:"skuId,omitempty"`
+}
+
+// DirectoryObject the microsoft.graph.directoryObject type
+type DirectoryObject struct {
+	Entity
+
+	DeletedDateTime *time.Time `json:"deletedDateTime,omitempty"`
+}
+
+// Entity the microsoft.graph.entity type
+type Entity struct {
+	ID string `json:"id,omitempty"`
+}
+
+// Group the microsoft.graph.group type
+type Group struct {
+	DirectoryObject
+
+	Classification               *string    `json:"classification,omitempty"`
+	CreatedDateTime              *time.Time `json:"createdDateTime,omitempty"`
+	Description                  *string    `json:"description,omitempty"`
+	DisplayName                  *string    `json:"displayName,omitempty"`
+	ExpirationDateTime           *time.Time `json:"expirationDateTime,omitempty"`
+	GroupTypes                   []string   `json:"groupTypes,omitempty"`
+	IsAssignableToRole           *bool      `json:"isAssignableToRole,omitempty"`
+	Mail                         *string    `json:"mail,omitempty"`
+	MailEnabled                  *bool      `json:"mailEnabled,omitempty"`
+	MailNickname                 *string    `json:"mailNickname,omitempty"`
+	MembershipRule               *string    `json:"membershipRule,omitempty"`
+	OnPremisesLastSyncDateTime   *time.Time `json:"onPremisesLastSyncDateTime,omitempty"`
+	OnPremisesSecurityIdentifier *string    `json:"onPremisesSecurityIdentifier,omitempty"`
+	OnPremisesSyncEnabled        *bool      `json:"onPremisesSyncEnabled,omitempty"`
+	PreferredDataLocation        *string    `json:"preferredDataLocation,omitempty"`
+	ProxyAddresses               []string   `json:"proxyAddresses,omitempty"`
+	RenewedDateTime              *time.Time `json:"renewedDateTime,omitempty"`
+	SecurityEnabled              *bool      `json:"securityEnabled,omitempty"`
+	SecurityIdentifier           *string    `json:"securityIdentifier,omitempty"`
+	Visibility                   *string    `json:"visibility,omitempty"`
+
+	// navigation properties, only set when expanded
+	CreatedOnBehalfOf *DirectoryObject  `json:"createdOnBehalfOf,omitempty"`
+	MemberOf          []DirectoryObject `json:"memberOf,omitempty"`
+	Members           []DirectoryObject `json:"members,omitempty"`
+	Owners            []DirectoryObject `json:"owners,omitempty"`
+}
+
+// NativeAuthenticationApisEnabled the microsoft.graph.nativeAuthenticationApisEnabled enumeration
+type NativeAuthenticationApisEnabled string
+
+const (
+	NativeAuthenticationApisEnabledNone               NativeAuthenticationApisEnabled = "none"
+	NativeAuthenticationApisEnabledAll                NativeAuthenticationApisEnabled = "all"
+	NativeAuthenticationApisEnabledUnknownFutureValue NativeAuthenticationApisEnabled = "unknownFutureValue"
+)
+
+// OnPremisesExtensionAttributes the microsoft.graph.onPremisesExtensionAttributes type
+type OnPremisesExtensionAttributes struct {
+	ExtensionAttribute1 *string `json:"extensionAttribute1,omitempty"`
+	ExtensionAttribute2 *string `json:"extensionAttribute2,omitempty"`
+	ExtensionAttribute3 *string `json:"extensionAttribute3,omitempty"`
+	ExtensionAttribute4 *string `json:"extensionAttribute4,omitempty"`
+	ExtensionAttribute5 *string `json:"extensionAttribute5,omitempty"`
+}
+
+// PasswordProfile the microsoft.graph.passwordProfile type
+type PasswordProfile struct {
+	ForceChangePasswordNextSignIn        *bool   `json:"forceChangePasswordNextSignIn,omitempty"`
+	ForceChangePasswordNextSignInWithMfa *bool   `json:"forceChangePasswordNextSignInWithMfa,omitempty"`
+	Password                             *string `json:"password,omitempty"`
+}
+
+// RequiredResourceAccess the microsoft.graph.requiredResourceAccess type
+type RequiredResourceAccess struct {
+	ResourceAccess []ResourceAccess `json:"resourceAccess,omitempty"`
+	ResourceAppID  string           `json:"resourceAppId,omitempty"`
+}
+
+// ResourceAccess the microsoft.graph.resourceAccess type
+type ResourceAccess struct {
+	ID   string  `json:"id,omitempty"`
+	Type *string `json:"type,omitempty"`
+}
+
+// User the microsoft.graph.user type
+type User struct {
+	DirectoryObject
+
+	AccountEnabled                *bool                          `json:"accountEnabled,omitempty"`
+	AgeGroup                      *string                        `json:"ageGroup,omitempty"`
+	AssignedLicenses              []AssignedLicense              `json:"assignedLicenses,omitempty"`
+	BusinessPhones                []string                       `json:"businessPhones,omitempty"`
+	City                          *string                        `json:"city,omitempty"`
+	CompanyName                   *string                        `json:"companyName,omitempty"`
+	Country                       *string                        `json:"country,omitempty"`
+	CreatedDateTime               *time.Time                     `json:"createdDateTime,omitempty"`
+	Department                    *string                        `json:"department,omitempty"`
+	DisplayName                   *string                        `json:"displayName,omitempty"`
+	EmployeeID                    *string                        `json:"employeeId,omitempty"`
+	EmployeeType                  *string                        `json:"employeeType,omitempty"`
+	GivenName                     *string                        `json:"givenName,omitempty"`
+	JobTitle                      *string                        `json:"jobTitle,omitempty"`
+	Mail                          *string                        `json:"mail,omitempty"`
+	MailNickname                  *string                        `json:"mailNickname,omitempty"`
+	MobilePhone                   *string                        `json:"mobilePhone,omitempty"`
+	OfficeLocation                *string                        `json:"officeLocation,omitempty"`
+	OnPremisesExtensionAttributes *OnPremisesExtensionAttributes `json:"onPremisesExtensionAttributes,omitempty"`
+	OnPremisesImmutableID         *string                        `json:"onPremisesImmutableId,omitempty"`
+	OnPremisesLastSyncDateTime    *time.Time                     `json:"onPremisesLastSyncDateTime,omitempty"`
+	OnPremisesSamAccountName      *string                        `json:"onPremisesSamAccountName,omitempty"`
+	OnPremisesSyncEnabled         *bool                          `json:"onPremisesSyncEnabled,omitempty"`
+	PasswordProfile               *PasswordProfile               `json:"passwordProfile,omitempty"`
+	PreferredLanguage             *string                        `json:"preferredLanguage,omitempty"`
+	ProxyAddresses                []string                       `json:"proxyAddresses,omitempty"`
+	Surname                       *string                        `json:"surname,omitempty"`
+	UsageLocation                 *string                        `json:"usageLocation,omitempty"`
+	UserPrincipalName             *string                        `json:"userPrincipalName,omitempty"`
+	UserType                      *string                        `json:"userType,omitempty"`
+
+	// navigation properties, only set when expanded
+	Manager       *DirectoryObject  `json:"manager,omitempty"`
+	DirectReports []DirectoryObject `json:"directReports,omitempty"`
+	MemberOf      []DirectoryObject `json:"memberOf,omitempty"`
+	OwnedObjects  []DirectoryObject `json:"ownedObjects,omitempty"`
+}
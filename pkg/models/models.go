@@ -0,0 +1,8 @@
+// Package models the Graph API entity types of the users, groups and applications entity sets, generated
+// from the trimmed $metadata copy in metadata/v1.0.xml, for programs decoding Graph responses with the
+// msgraph.Client services. The command line has its own resource types in pkg/resources, which tag personal
+// data for redaction. Add properties to metadata/v1.0.xml rather than to the generated file, then run
+// go generate ./pkg/models.
+package models
+
+//go:generate go run ../../cmd/msgraph-gen --metadata metadata/v1.0.xml --sets users,groups,applications --out models.gen.go
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"westpac.co.nz/msgraph/pkg/csdl"
+	"westpac.co.nz/msgraph/pkg/graphtest"
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+func TestGeneratedUpToDate(t *testing.T) {
+	file, err := os.Open("metadata/v1.0.xml")
+	require.NoError(t, err)
+	defer file.Close()
+	metadata, err := csdl.Parse(file)
+	require.NoError(t, err)
+
+	expected, err := csdl.Generate(metadata, csdl.Config{
+		Package:    "models",
+		Source:     "metadata/v1.0.xml",
+		EntitySets: []string{"users", "groups", "applications"},
+	})
+	require.NoError(t, err)
+	generated, err := ioutil.ReadFile("models.gen.go")
+	require.NoError(t, err)
+
+	assert.Equal(t, string(expected), string(generated), "run go generate ./pkg/models")
+}
+
+func TestUserJSON(t *testing.T) {
+	body := `{"id":"1","displayName":"Adele Vance","accountEnabled":false,"department":"Retail",
+		"businessPhones":["+1 425 555 0109"],"createdDateTime":"2021-03-01T10:00:00Z","manager":{"id":"2"}}`
+
+	var user User
+	require.NoError(t, json.Unmarshal([]byte(body), &user))
+
+	assert.Equal(t, "1", user.ID)
+	require.NotNil(t, user.AccountEnabled)
+	assert.False(t, *user.AccountEnabled)
+	assert.Equal(t, "Retail", *user.Department)
+	assert.Equal(t, "2", user.Manager.ID)
+	assert.Nil(t, user.JobTitle)
+
+	encoded, err := json.Marshal(user)
+	require.NoError(t, err)
+	assert.JSONEq(t, body, string(encoded))
+}
+
+func TestClientDecodes(t *testing.T) {
+	server, err := graphtest.NewServer(graphtest.DefaultFixtures())
+	require.NoError(t, err)
+	defer server.Close()
+	client := msgraph.NewClient(
+		msgraph.WithBaseURL(server.URL),
+		msgraph.WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: graphtest.AccessToken})),
+	)
+
+	var users []User
+	require.NoError(t, client.Users.List(context.Background(), nil, &users))
+
+	require.NotEmpty(t, users)
+	assert.Equal(t, "Adele Vance", *users[0].DisplayName)
+	assert.True(t, *users[0].AccountEnabled)
+	assert.Equal(t, "CC-100", *users[0].OnPremisesExtensionAttributes.ExtensionAttribute1)
+}
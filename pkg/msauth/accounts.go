@@ -0,0 +1,194 @@
+package msauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+// Account a signed in user and its tokens, cached per tenant and user
+type Account struct {
+	Tenant        string        `json:"tenant"`
+	ClientID      string        `json:"clientId"`
+	Username      string        `json:"username"`
+	AuthorityHost string        `json:"authorityHost,omitempty"`
+	Token         *oauth2.Token `json:"token"`
+}
+
+// Accounts the on disk cache of signed in users
+type Accounts struct {
+	path string
+
+	Accounts []Account `json:"accounts"`
+	// Current the last signed in user of each tenant
+	Current map[string]string `json:"current"`
+}
+
+// DefaultAccountsPath the accounts cache in the user configuration directory
+func DefaultAccountsPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "msgraph", "accounts.json")
+}
+
+// LoadAccounts reads the accounts cache at path, a missing file is an empty cache
+func LoadAccounts(path string) (*Accounts, error) {
+	accounts := &Accounts{path: path, Current: map[string]string{}}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return accounts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, accounts); err != nil {
+		return nil, err
+	}
+	if accounts.Current == nil {
+		accounts.Current = map[string]string{}
+	}
+	return accounts, nil
+}
+
+// Save writes the cache, readable by the current user only. The file is replaced atomically while holding
+// the lock the processes sharing it take to write.
+func (a *Accounts) Save() error {
+	unlock, err := lockFile(a.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return a.write()
+}
+
+func (a *Accounts) write() error {
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	return replaceFile(a.path, data)
+}
+
+// SaveToken stores the refreshed token of the account. The cache is read again under the lock and only the
+// token of the account is changed, so the tokens other processes refreshed meanwhile are kept.
+func (a *Accounts) SaveToken(account Account, token *oauth2.Token) error {
+	if current, found := a.Find(account.Tenant, account.Username); found {
+		current.Token = token
+	}
+
+	unlock, err := lockFile(a.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	saved, err := LoadAccounts(a.path)
+	if err != nil {
+		return err
+	}
+	stored, found := saved.Find(account.Tenant, account.Username)
+	if !found {
+		// signed out meanwhile
+		return nil
+	}
+	stored.Token = token
+	return saved.write()
+}
+
+// Find returns the account of username in tenant, the current user of the tenant when username is empty
+func (a *Accounts) Find(tenant string, username string) (*Account, bool) {
+	if username == "" {
+		username = a.Current[tenant]
+	}
+	for index := range a.Accounts {
+		account := &a.Accounts[index]
+		if strings.EqualFold(account.Tenant, tenant) && strings.EqualFold(account.Username, username) {
+			return account, true
+		}
+	}
+	return nil, false
+}
+
+// Add stores the account, replacing a previous sign in of the same user, and makes it the current user
+func (a *Accounts) Add(account Account) {
+	a.Remove(account.Tenant, account.Username)
+	a.Accounts = append(a.Accounts, account)
+	a.Current[account.Tenant] = account.Username
+}
+
+// Remove deletes the account of username in tenant, every account of the tenant when username is empty
+func (a *Accounts) Remove(tenant string, username string) {
+	remaining := a.Accounts[:0]
+	for _, account := range a.Accounts {
+		sameTenant := strings.EqualFold(account.Tenant, tenant)
+		if sameTenant && (username == "" || strings.EqualFold(account.Username, username)) {
+			continue
+		}
+		remaining = append(remaining, account)
+	}
+	a.Accounts = remaining
+
+	if username == "" || strings.EqualFold(a.Current[tenant], username) {
+		delete(a.Current, tenant)
+	}
+}
+
+// savingTokenSource writes refreshed tokens back to the accounts cache
+type savingTokenSource struct {
+	source   oauth2.TokenSource
+	accounts *Accounts
+	account  Account
+}
+
+func (s *savingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	if s.account.Token == nil || token.AccessToken != s.account.Token.AccessToken {
+		s.account.Token = token
+		if err := s.accounts.SaveToken(s.account, token); err != nil {
+			log.Warn("Could not save refreshed token: ", err)
+		}
+	}
+	return token, nil
+}
+
+// NewAccountTokenSource Returns a TokenSource for the cached account, refreshing it with its refresh token when expired
+func NewAccountTokenSource(ctx context.Context, config *oauth2.Config, accounts *Accounts, account Account) oauth2.TokenSource {
+	source := &savingTokenSource{
+		source:   config.TokenSource(ctx, account.Token),
+		accounts: accounts,
+		account:  account,
+	}
+	return oauth2.ReuseTokenSource(account.Token, source)
+}
+
+// ErrNotLoggedIn no cached account for the tenant
+var ErrNotLoggedIn = errors.New("not logged in, run the login command first")
+
+//GetOAuth2LoginClient Returns a OAuth2 http.Client instance for the cached sign in of username in tenant
+func GetOAuth2LoginClient(tenant string, username string) (*http.Client, error) {
+	source, err := GetLoginTokenSource(tenant, username)
+	if err != nil {
+		return nil, err
+	}
+	return oauth2.NewClient(context.Background(), source), nil
+}
+
+//GetLoginTokenSource Returns a TokenSource for the cached sign in of username in tenant
+func GetLoginTokenSource(tenant string, username string) (oauth2.TokenSource, error) {
+	credentials := Credentials{Method: MethodLogin, Tenant: tenant, Account: username, AccountsPath: DefaultAccountsPath()}
+	return NewTokenSource(context.Background(), credentials)
+}
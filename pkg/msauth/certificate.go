@@ -0,0 +1,209 @@
+package msauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/pkcs12"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// ClientAssertionType the client_assertion_type of a JWT client assertion
+const ClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// assertionLifetime how long a signed client assertion is valid for
+const assertionLifetime = 10 * time.Minute
+
+// Certificate a client certificate and the private key used to sign client assertions
+type Certificate struct {
+	Certificate *x509.Certificate
+	PrivateKey  *rsa.PrivateKey
+}
+
+// LoadCertificate reads the certificate and RSA private key from PEM files, or from a PFX/PKCS#12 file
+// (.pfx or .p12 extension) protected by password. keyFile may be empty when certFile also holds the key.
+func LoadCertificate(certFile string, keyFile string, password string) (*Certificate, error) {
+	certData, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []*pem.Block
+	switch strings.ToLower(filepath.Ext(certFile)) {
+	case ".pfx", ".p12":
+		blocks, err = pkcs12.ToPEM(certData, password)
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", certFile, err)
+		}
+	default:
+		blocks = decodePEM(certData)
+	}
+
+	if keyFile != "" {
+		keyData, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, decodePEM(keyData)...)
+	}
+
+	certificate := &Certificate{}
+	var certificates []*x509.Certificate
+	for _, block := range blocks {
+		switch {
+		case block.Type == "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			certificates = append(certificates, cert)
+		case strings.HasSuffix(block.Type, "PRIVATE KEY") && certificate.PrivateKey == nil:
+			certificate.PrivateKey, err = parsePrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if certificate.PrivateKey == nil {
+		return nil, errors.New("no RSA private key found")
+	}
+	// a file may hold the whole chain, the client certificate is the one matching the key
+	for _, cert := range certificates {
+		if publicKey, ok := cert.PublicKey.(*rsa.PublicKey); ok && publicKey.Equal(&certificate.PrivateKey.PublicKey) {
+			certificate.Certificate = cert
+		}
+	}
+	if certificate.Certificate == nil {
+		return nil, errors.New("no certificate matching the private key found")
+	}
+	return certificate, nil
+}
+
+func decodePEM(data []byte) []*pem.Block {
+	var blocks []*pem.Block
+	for {
+		block, rest := pem.Decode(data)
+		if block == nil {
+			return blocks
+		}
+		blocks = append(blocks, block)
+		data = rest
+	}
+}
+
+func parsePrivateKey(der []byte) (*rsa.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("only RSA private keys are supported")
+	}
+	return rsaKey, nil
+}
+
+// Thumbprint the base64url encoded SHA-1 hash of the certificate, as used in the x5t header
+func (c *Certificate) Thumbprint() string {
+	hash := sha1.Sum(c.Certificate.Raw)
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// ClientAssertion returns a JWT for clientID, valid for audience tokenURL, signed with the private key
+func (c *Certificate) ClientAssertion(clientID string, tokenURL string) (string, error) {
+	header := map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+		"x5t": c.Thumbprint(),
+	}
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims := map[string]interface{}{
+		"aud": tokenURL,
+		"iss": clientID,
+		"sub": clientID,
+		"jti": fmt.Sprintf("%x", jti),
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": now.Add(assertionLifetime).Unix(),
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	hash := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, c.PrivateKey, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// assertionTokenSource requests client credential tokens authenticating with a freshly created client assertion
+type assertionTokenSource struct {
+	ctx       context.Context
+	config    clientcredentials.Config
+	assertion func() (string, error)
+}
+
+func (s assertionTokenSource) Token() (*oauth2.Token, error) {
+	assertion, err := s.assertion()
+	if err != nil {
+		return nil, fmt.Errorf("creating client assertion: %w", err)
+	}
+
+	config := s.config
+	config.AuthStyle = oauth2.AuthStyleInParams
+	config.EndpointParams = url.Values{
+		"client_assertion_type": []string{ClientAssertionType},
+		"client_assertion":      []string{assertion},
+	}
+	return config.Token(s.ctx)
+}
+
+// NewCertificateTokenSource Returns a TokenSource requesting tokens from tokenURL with a client assertion signed by certificate
+func NewCertificateTokenSource(ctx context.Context, tokenURL string, clientID string, scopes []string, certificate *Certificate) oauth2.TokenSource {
+	source := assertionTokenSource{
+		ctx: ctx,
+		config: clientcredentials.Config{
+			ClientID: clientID,
+			Scopes:   scopes,
+			TokenURL: tokenURL,
+		},
+		assertion: func() (string, error) {
+			return certificate.ClientAssertion(clientID, tokenURL)
+		},
+	}
+	return oauth2.ReuseTokenSource(nil, source)
+}
@@ -0,0 +1,114 @@
+package msauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type CertificateTestSuite struct {
+	suite.Suite
+	server      *httptest.Server
+	assertions  []string
+	certificate *Certificate
+}
+
+func (suite *CertificateTestSuite) SetupTest() {
+	suite.assertions = nil
+	suite.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(suite.T(), r.ParseForm())
+		assert.Equal(suite.T(), "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(suite.T(), "client-id", r.PostForm.Get("client_id"))
+		assert.Equal(suite.T(), ClientAssertionType, r.PostForm.Get("client_assertion_type"))
+		assert.Empty(suite.T(), r.PostForm.Get("client_secret"))
+		suite.assertions = append(suite.assertions, r.PostForm.Get("client_assertion"))
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"token","token_type":"Bearer","expires_in":3600}`)
+	}))
+}
+
+func (suite *CertificateTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *CertificateTestSuite) TestLoadPEM() {
+	certificate, err := LoadCertificate("testdata/client.pem", "testdata/client.key", "")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "msgraph-test", certificate.Certificate.Subject.CommonName)
+}
+
+func (suite *CertificateTestSuite) TestLoadPFX() {
+	certificate, err := LoadCertificate("testdata/client.pfx", "", "test")
+	assert.NoError(suite.T(), err)
+
+	pemCertificate, err := LoadCertificate("testdata/client.pem", "testdata/client.key", "")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), pemCertificate.Thumbprint(), certificate.Thumbprint())
+
+	_, err = LoadCertificate("testdata/client.pfx", "", "wrong")
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CertificateTestSuite) TestMissingKey() {
+	_, err := LoadCertificate("testdata/client.pem", "", "")
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CertificateTestSuite) TestTokenSource() {
+	certificate, err := LoadCertificate("testdata/client.pem", "testdata/client.key", "")
+	assert.NoError(suite.T(), err)
+
+	tokenURL := suite.server.URL + "/tenant/oauth2/v2.0/token"
+	source := NewCertificateTokenSource(context.Background(), tokenURL, "client-id", DefaultScopes, certificate)
+
+	token, err := source.Token()
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "token", token.AccessToken)
+
+	// the token is reused until it expires
+	_, err = source.Token()
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), suite.assertions, 1)
+
+	parts := strings.Split(suite.assertions[0], ".")
+	assert.Len(suite.T(), parts, 3)
+
+	var header map[string]string
+	decodeSegment(suite.T(), parts[0], &header)
+	assert.Equal(suite.T(), "RS256", header["alg"])
+	assert.Equal(suite.T(), certificate.Thumbprint(), header["x5t"])
+
+	var claims map[string]interface{}
+	decodeSegment(suite.T(), parts[1], &claims)
+	assert.Equal(suite.T(), tokenURL, claims["aud"])
+	assert.Equal(suite.T(), "client-id", claims["iss"])
+	assert.Equal(suite.T(), "client-id", claims["sub"])
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	assert.NoError(suite.T(), err)
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	publicKey := certificate.Certificate.PublicKey.(*rsa.PublicKey)
+	assert.NoError(suite.T(), rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash[:], signature))
+}
+
+func decodeSegment(t *testing.T, segment string, v interface{}) {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, v))
+}
+
+func TestCertificateTestSuite(t *testing.T) {
+	suite.Run(t, new(CertificateTestSuite))
+}
@@ -0,0 +1,218 @@
+package msauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+)
+
+// Authentication methods of Credentials
+const (
+	MethodAuto        = "auto"
+	MethodSecret      = "secret"
+	MethodCertificate = "certificate"
+	MethodWorkload    = "workload"
+	MethodMSI         = "msi"
+	MethodLogin       = "login"
+)
+
+// Methods the authentication methods in the order the auto detection tries them
+var Methods = []string{MethodAuto, MethodSecret, MethodCertificate, MethodWorkload, MethodMSI, MethodLogin}
+
+// ErrCredentialUnavailable the method is not configured in this environment, the auto detection moves on
+var ErrCredentialUnavailable = errors.New("credential unavailable")
+
+func unavailable(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrCredentialUnavailable, fmt.Sprintf(format, args...))
+}
+
+// Credentials everything needed to pick an authentication method and build its TokenSource
+type Credentials struct {
+	// Method one of Methods, auto tries SPN credentials, workload identity, managed identity and the cached login in turn
+	Method string
+
+	Tenant       string
+	ClientID     string
+	ClientSecret string
+	CertFile     string
+	KeyFile      string
+	CertPassword string
+
+	// FederatedTokenFile the workload identity token file, AZURE_FEDERATED_TOKEN_FILE by default
+	FederatedTokenFile string
+	// AuthorityHost the login host, AZURE_AUTHORITY_HOST or the public cloud by default
+	AuthorityHost string
+	// Scopes the client credential scopes, DefaultScopes when empty
+	Scopes []string
+	// ManagedIdentityEndpoint the managed identity token endpoint, IDENTITY_ENDPOINT or IMDS by default
+	ManagedIdentityEndpoint string
+
+	// Account the cached login user, the last signed in user of the tenant when empty
+	Account      string
+	AccountsPath string
+
+	// Cache optional cache for the tokens of the SPN and workload identity methods
+	Cache *TokenCache
+}
+
+// CredentialsFromEnvironment Returns Credentials with the workload and managed identity settings of the platform
+func CredentialsFromEnvironment() Credentials {
+	return Credentials{
+		Method:                  MethodAuto,
+		Tenant:                  os.Getenv("AZURE_TENANT_ID"),
+		ClientID:                os.Getenv("AZURE_CLIENT_ID"),
+		FederatedTokenFile:      os.Getenv("AZURE_FEDERATED_TOKEN_FILE"),
+		AuthorityHost:           os.Getenv("AZURE_AUTHORITY_HOST"),
+		ManagedIdentityEndpoint: os.Getenv("IDENTITY_ENDPOINT"),
+		AccountsPath:            DefaultAccountsPath(),
+	}
+}
+
+// TokenURL the v2 token endpoint of the tenant on the authority host
+func (c Credentials) TokenURL() string {
+	return AzureADEndpoint(c.AuthorityHost, c.Tenant).TokenURL
+}
+
+func (c Credentials) scopes() []string {
+	if len(c.Scopes) == 0 {
+		return DefaultScopes
+	}
+	return c.Scopes
+}
+
+// resource the managed identity resource (audience) of the requested scopes
+func (c Credentials) resource() string {
+	return strings.TrimSuffix(c.scopes()[0], ".default")
+}
+
+func (c Credentials) cached(source oauth2.TokenSource) oauth2.TokenSource {
+	if c.Cache == nil {
+		return source
+	}
+	return NewCachedTokenSource(c.Cache, TokenCacheKey(c.Tenant, c.ClientID, c.scopes()), source)
+}
+
+func (c Credentials) requireTenantAndClient(method string) error {
+	if c.Tenant == "" || c.ClientID == "" {
+		return fmt.Errorf("%s authentication needs a tenant and a client ID", method)
+	}
+	return nil
+}
+
+// source the TokenSource of a single method, ErrCredentialUnavailable when the method is not configured
+func (c Credentials) source(ctx context.Context, method string) (oauth2.TokenSource, error) {
+	switch method {
+	case MethodSecret:
+		if c.ClientSecret == "" {
+			return nil, unavailable("no client secret")
+		}
+		if err := c.requireTenantAndClient(method); err != nil {
+			return nil, err
+		}
+		return c.cached(newClientSecretTokenSource(ctx, c.TokenURL(), c.ClientID, c.ClientSecret, c.scopes())), nil
+	case MethodCertificate:
+		if c.CertFile == "" {
+			return nil, unavailable("no client certificate")
+		}
+		if err := c.requireTenantAndClient(method); err != nil {
+			return nil, err
+		}
+		certificate, err := LoadCertificate(c.CertFile, c.KeyFile, c.CertPassword)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		return c.cached(NewCertificateTokenSource(ctx, c.TokenURL(), c.ClientID, c.scopes(), certificate)), nil
+	case MethodWorkload:
+		if c.FederatedTokenFile == "" {
+			return nil, unavailable("no federated token file")
+		}
+		if err := c.requireTenantAndClient(method); err != nil {
+			return nil, err
+		}
+		return c.cached(NewWorkloadIdentityTokenSource(ctx, c.TokenURL(), c.ClientID, c.scopes(), c.FederatedTokenFile)), nil
+	case MethodMSI:
+		endpoint := c.ManagedIdentityEndpoint
+		if endpoint == "" {
+			endpoint = DefaultManagedIdentityEndpoint
+		}
+		return NewManagedIdentityTokenSource(ctx, endpoint, os.Getenv("IDENTITY_HEADER"), c.resource(), c.ClientID), nil
+	case MethodLogin:
+		accounts, err := LoadAccounts(c.AccountsPath)
+		if err != nil {
+			return nil, err
+		}
+		account, found := accounts.Find(c.Tenant, c.Account)
+		if !found {
+			return nil, unavailable("%v", ErrNotLoggedIn)
+		}
+		authorityHost := account.AuthorityHost
+		if authorityHost == "" {
+			authorityHost = c.AuthorityHost
+		}
+		config := NewDelegatedConfig(authorityHost, account.Tenant, account.ClientID, DelegatedScopes)
+		return NewAccountTokenSource(ctx, config, accounts, *account), nil
+	}
+	return nil, fmt.Errorf("unknown authentication method '%s', expected one of %s", method, Methods)
+}
+
+// NewTokenSource Returns the TokenSource of the configured method, or for auto the first method of the
+// environment -> workload identity -> managed identity -> cached login chain that produces a token
+func NewTokenSource(ctx context.Context, credentials Credentials) (oauth2.TokenSource, error) {
+	if credentials.Method != "" && credentials.Method != MethodAuto {
+		source, err := credentials.source(ctx, credentials.Method)
+		if errors.Is(err, ErrCredentialUnavailable) {
+			return nil, fmt.Errorf("%s authentication not configured: %w", credentials.Method, err)
+		}
+		return source, err
+	}
+
+	chain := &chainTokenSource{}
+	for _, method := range []string{MethodSecret, MethodCertificate, MethodWorkload, MethodMSI, MethodLogin} {
+		source, err := credentials.source(ctx, method)
+		switch {
+		case errors.Is(err, ErrCredentialUnavailable):
+			log.Debugf("Skipping %s authentication: %v", method, err)
+		case err != nil:
+			return nil, err
+		default:
+			chain.methods = append(chain.methods, method)
+			chain.sources = append(chain.sources, source)
+		}
+	}
+	return chain, nil
+}
+
+// chainTokenSource uses the first of its sources that is available, and sticks with it
+type chainTokenSource struct {
+	methods  []string
+	sources  []oauth2.TokenSource
+	selected oauth2.TokenSource
+}
+
+func (c *chainTokenSource) Token() (*oauth2.Token, error) {
+	if c.selected != nil {
+		return c.selected.Token()
+	}
+
+	var failures []string
+	for index, source := range c.sources {
+		token, err := source.Token()
+		if errors.Is(err, ErrCredentialUnavailable) {
+			log.Debugf("Skipping %s authentication: %v", c.methods[index], err)
+			failures = append(failures, fmt.Sprintf("%s: %v", c.methods[index], err))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s authentication failed: %w", c.methods[index], err)
+		}
+		log.Debugf("Authenticated using %s", c.methods[index])
+		c.selected = source
+		return token, nil
+	}
+	return nil, fmt.Errorf("no credentials found, set a client secret or certificate, use a workload or managed identity, or login (%s)", strings.Join(failures, "; "))
+}
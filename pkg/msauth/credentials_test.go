@@ -0,0 +1,155 @@
+package msauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/oauth2"
+)
+
+type CredentialsTestSuite struct {
+	suite.Suite
+	dir         string
+	tokenServer *httptest.Server
+	imdsServer  *httptest.Server
+	imdsStatus  int
+}
+
+func (suite *CredentialsTestSuite) SetupTest() {
+	var err error
+	suite.dir, err = ioutil.TempDir("", "msauth")
+	assert.NoError(suite.T(), err)
+
+	suite.tokenServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(suite.T(), "/tenant/oauth2/v2.0/token", r.URL.Path)
+		assert.Equal(suite.T(), "federated-token", r.FormValue("client_assertion"))
+		assert.Equal(suite.T(), ClientAssertionType, r.FormValue("client_assertion_type"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"workload","token_type":"Bearer","expires_in":3600}`)
+	}))
+
+	suite.imdsStatus = http.StatusOK
+	suite.imdsServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(suite.T(), "https://graph.microsoft.com/", r.URL.Query().Get("resource"))
+		if r.Header.Get("Metadata") != "true" && r.Header.Get("X-IDENTITY-HEADER") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(suite.imdsStatus)
+		expiresOn := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+		fmt.Fprintf(w, `{"access_token":"msi","token_type":"Bearer","expires_in":"3600","expires_on":%q}`, expiresOn)
+	}))
+}
+
+func (suite *CredentialsTestSuite) TearDownTest() {
+	suite.tokenServer.Close()
+	suite.imdsServer.Close()
+	os.RemoveAll(suite.dir)
+}
+
+func (suite *CredentialsTestSuite) credentials() Credentials {
+	return Credentials{
+		Method:                  MethodAuto,
+		Tenant:                  "tenant",
+		ClientID:                "client-id",
+		AuthorityHost:           suite.tokenServer.URL,
+		ManagedIdentityEndpoint: suite.imdsServer.URL,
+		AccountsPath:            filepath.Join(suite.dir, "accounts.json"),
+	}
+}
+
+func (suite *CredentialsTestSuite) token(credentials Credentials) (*oauth2.Token, error) {
+	source, err := NewTokenSource(context.Background(), credentials)
+	if err != nil {
+		return nil, err
+	}
+	return source.Token()
+}
+
+func (suite *CredentialsTestSuite) TestWorkloadIdentity() {
+	tokenFile := filepath.Join(suite.dir, "token")
+	assert.NoError(suite.T(), ioutil.WriteFile(tokenFile, []byte("federated-token\n"), 0600))
+
+	credentials := suite.credentials()
+	credentials.FederatedTokenFile = tokenFile
+
+	token, err := suite.token(credentials)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "workload", token.AccessToken)
+}
+
+func (suite *CredentialsTestSuite) TestManagedIdentity() {
+	token, err := suite.token(suite.credentials())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "msi", token.AccessToken)
+	assert.WithinDuration(suite.T(), time.Now().Add(time.Hour), token.Expiry, time.Minute)
+}
+
+func (suite *CredentialsTestSuite) TestAppServiceManagedIdentity() {
+	os.Setenv("IDENTITY_HEADER", "secret")
+	defer os.Unsetenv("IDENTITY_HEADER")
+
+	credentials := suite.credentials()
+	credentials.Method = MethodMSI
+	token, err := suite.token(credentials)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "msi", token.AccessToken)
+}
+
+func (suite *CredentialsTestSuite) TestManagedIdentityError() {
+	suite.imdsStatus = http.StatusInternalServerError
+
+	_, err := suite.token(suite.credentials())
+	assert.Error(suite.T(), err)
+	assert.False(suite.T(), errors.Is(err, ErrCredentialUnavailable))
+}
+
+func (suite *CredentialsTestSuite) TestCachedLogin() {
+	accounts, err := LoadAccounts(filepath.Join(suite.dir, "accounts.json"))
+	assert.NoError(suite.T(), err)
+	accounts.Add(Account{
+		Tenant:   "tenant",
+		ClientID: "public-client",
+		Username: "jane@example.com",
+		Token:    &oauth2.Token{AccessToken: "login", Expiry: time.Now().Add(time.Hour)},
+	})
+	assert.NoError(suite.T(), accounts.Save())
+
+	// no managed identity listening, the chain moves on to the login
+	suite.imdsServer.Close()
+
+	token, err := suite.token(suite.credentials())
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "login", token.AccessToken)
+}
+
+func (suite *CredentialsTestSuite) TestNothingAvailable() {
+	suite.imdsServer.Close()
+
+	_, err := suite.token(suite.credentials())
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "no credentials found")
+}
+
+func (suite *CredentialsTestSuite) TestExplicitMethodNotConfigured() {
+	credentials := suite.credentials()
+	credentials.Method = MethodCertificate
+
+	_, err := suite.token(credentials)
+	assert.True(suite.T(), errors.Is(err, ErrCredentialUnavailable))
+}
+
+func TestCredentialsTestSuite(t *testing.T) {
+	suite.Run(t, new(CredentialsTestSuite))
+}
@@ -0,0 +1,279 @@
+package msauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// DelegatedScopes the scopes requested when signing in as a user, offline_access returns a refresh token
+var DelegatedScopes = []string{"https://graph.microsoft.com/.default", "offline_access", "openid", "profile"}
+
+// DeviceCodeGrantType the grant_type used to poll for a device code token
+const DeviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// pollUnit the unit of the polling interval returned by the device code endpoint
+var pollUnit = time.Second
+
+// DeviceCode the device code endpoint response, Message tells the user where to enter UserCode
+type DeviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+	Message         string `json:"message"`
+}
+
+// tokenResponse a token endpoint response, including the error fields returned while a device code is pending
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+//NewDelegatedConfig Returns the OAuth2 config of the public client application clientID signing users in to tenant
+func NewDelegatedConfig(authorityHost string, tenant string, clientID string, scopes []string) *oauth2.Config {
+	endpoint := AzureADEndpoint(authorityHost, tenant)
+	// public clients have no secret, never send one in a basic auth header
+	endpoint.AuthStyle = oauth2.AuthStyleInParams
+
+	return &oauth2.Config{
+		ClientID: clientID,
+		Endpoint: endpoint,
+		Scopes:   scopes,
+	}
+}
+
+// deviceCodeURL the device authorization endpoint next to the token endpoint
+func deviceCodeURL(config *oauth2.Config) string {
+	return strings.TrimSuffix(config.Endpoint.TokenURL, "/token") + "/devicecode"
+}
+
+// DeviceCodeLogin signs a user in using the device code flow. prompt is called with the code the user
+// has to enter, the function then polls the token endpoint until the user completes the sign in.
+func DeviceCodeLogin(ctx context.Context, config *oauth2.Config, prompt func(DeviceCode)) (*oauth2.Token, error) {
+	var deviceCode DeviceCode
+	err := postForm(ctx, deviceCodeURL(config), url.Values{
+		"client_id": []string{config.ClientID},
+		"scope":     []string{strings.Join(config.Scopes, " ")},
+	}, &deviceCode)
+	if err != nil {
+		return nil, fmt.Errorf("requesting device code: %w", err)
+	}
+	prompt(deviceCode)
+
+	interval := time.Duration(deviceCode.Interval) * pollUnit
+	if deviceCode.Interval == 0 {
+		interval = 5 * pollUnit
+	}
+	deadline := time.Now().Add(time.Duration(deviceCode.ExpiresIn) * time.Second)
+
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		var response tokenResponse
+		err := postForm(ctx, config.Endpoint.TokenURL, url.Values{
+			"grant_type":  []string{DeviceCodeGrantType},
+			"client_id":   []string{config.ClientID},
+			"device_code": []string{deviceCode.DeviceCode},
+		}, &response)
+
+		switch response.Error {
+		case "":
+			if err != nil {
+				return nil, err
+			}
+			return response.token(), nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * pollUnit
+		default:
+			return nil, fmt.Errorf("device code login failed: %s: %s", response.Error, response.ErrorDescription)
+		}
+	}
+	return nil, errors.New("device code expired before the sign in completed")
+}
+
+// InteractiveLogin signs a user in with the authorization code flow and PKCE. A listener on a random
+// localhost port receives the redirect, openBrowser is called with the sign in URL.
+func InteractiveLogin(ctx context.Context, config *oauth2.Config, openBrowser func(string) error) (*oauth2.Token, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+
+	redirectConfig := *config
+	redirectConfig.RedirectURL = fmt.Sprintf("http://localhost:%d", listener.Addr().(*net.TCPAddr).Port)
+
+	verifier, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	authURL := redirectConfig.AuthCodeURL(state,
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:])),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	)
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	report := func(res result) {
+		// only the first redirect counts, later ones must not block their handler
+		select {
+		case results <- res:
+		default:
+		}
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case r.URL.Path != "/" || (query.Get("code") == "" && query.Get("error") == ""):
+			// not the redirect, e.g. a favicon request or a port probe
+			http.NotFound(w, r)
+		case query.Get("state") != state:
+			http.Error(w, "Sign in failed: state mismatch", http.StatusBadRequest)
+			report(result{err: errors.New("state mismatch in redirect")})
+		case query.Get("error") != "":
+			http.Error(w, "Sign in failed: "+query.Get("error_description"), http.StatusBadRequest)
+			report(result{err: fmt.Errorf("sign in failed: %s: %s", query.Get("error"), query.Get("error_description"))})
+		default:
+			fmt.Fprint(w, "Signed in, you can close this window.")
+			report(result{code: query.Get("code")})
+		}
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	if err := openBrowser(authURL); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-results:
+		if res.err != nil {
+			return nil, res.err
+		}
+		return redirectConfig.Exchange(ctx, res.code, oauth2.SetAuthURLParam("code_verifier", verifier))
+	}
+}
+
+// OpenBrowser opens url in the default browser of the platform
+func OpenBrowser(url string) error {
+	switch runtime.GOOS {
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	case "darwin":
+		return exec.Command("open", url).Start()
+	default:
+		return exec.Command("xdg-open", url).Start()
+	}
+}
+
+func randomString() (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func postForm(ctx context.Context, endpoint string, values url.Values, v interface{}) error {
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%s: %s", resp.Status, body)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, body)
+	}
+	return nil
+}
+
+func (t tokenResponse) token() *oauth2.Token {
+	token := &oauth2.Token{
+		AccessToken:  t.AccessToken,
+		TokenType:    t.TokenType,
+		RefreshToken: t.RefreshToken,
+	}
+	if t.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(t.ExpiresIn) * time.Second)
+	}
+	return token.WithExtra(map[string]interface{}{"id_token": t.IDToken})
+}
+
+// TokenUsername the signed in user of a delegated token, read from the id token or the access token claims
+func TokenUsername(token *oauth2.Token) string {
+	candidates := []string{token.AccessToken}
+	if idToken, ok := token.Extra("id_token").(string); ok && idToken != "" {
+		candidates = append([]string{idToken}, candidates...)
+	}
+
+	for _, jwt := range candidates {
+		parts := strings.Split(jwt, ".")
+		if len(parts) != 3 {
+			continue
+		}
+		payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			continue
+		}
+		var claims map[string]interface{}
+		if json.Unmarshal(payload, &claims) != nil {
+			continue
+		}
+		for _, claim := range []string{"preferred_username", "upn", "unique_name", "email"} {
+			if username, ok := claims[claim].(string); ok && username != "" {
+				return username
+			}
+		}
+	}
+	return ""
+}
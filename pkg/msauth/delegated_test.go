@@ -0,0 +1,187 @@
+package msauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/oauth2"
+)
+
+// idToken an unsigned JWT with a preferred_username claim
+func idToken(username string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"preferred_username":%q}`, username)))
+	return "e30." + payload + ".sig"
+}
+
+type DelegatedTestSuite struct {
+	suite.Suite
+	server    *httptest.Server
+	config    *oauth2.Config
+	polls     int
+	challenge string
+}
+
+func (suite *DelegatedTestSuite) SetupTest() {
+	pollUnit = time.Millisecond
+	suite.polls = 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tenant/oauth2/v2.0/devicecode", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(suite.T(), "client-id", r.FormValue("client_id"))
+		fmt.Fprint(w, `{"device_code":"device","user_code":"ABC","verification_uri":"https://microsoft.com/devicelogin","expires_in":60,"interval":1,"message":"enter ABC"}`)
+	})
+	mux.HandleFunc("/tenant/oauth2/v2.0/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.FormValue("grant_type") {
+		case DeviceCodeGrantType:
+			assert.Equal(suite.T(), "device", r.FormValue("device_code"))
+			suite.polls++
+			if suite.polls < 3 {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error":"authorization_pending"}`)
+				return
+			}
+		case "authorization_code":
+			verifier := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+			assert.Equal(suite.T(), suite.challenge, base64.RawURLEncoding.EncodeToString(verifier[:]))
+			assert.Equal(suite.T(), "code", r.FormValue("code"))
+		}
+		fmt.Fprintf(w, `{"access_token":"access","refresh_token":"refresh","token_type":"Bearer","expires_in":3600,"id_token":%q}`, idToken("jane@example.com"))
+	})
+	suite.server = httptest.NewServer(mux)
+
+	suite.config = NewDelegatedConfig(suite.server.URL, "tenant", "client-id", DelegatedScopes)
+}
+
+func (suite *DelegatedTestSuite) TearDownTest() {
+	suite.server.Close()
+	pollUnit = time.Second
+}
+
+func (suite *DelegatedTestSuite) TestDeviceCodeLogin() {
+	var prompted DeviceCode
+	token, err := DeviceCodeLogin(context.Background(), suite.config, func(code DeviceCode) {
+		prompted = code
+	})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "enter ABC", prompted.Message)
+	assert.Equal(suite.T(), 3, suite.polls)
+	assert.Equal(suite.T(), "refresh", token.RefreshToken)
+	assert.Equal(suite.T(), "jane@example.com", TokenUsername(token))
+}
+
+func (suite *DelegatedTestSuite) TestInteractiveLogin() {
+	token, err := InteractiveLogin(context.Background(), suite.config, func(authURL string) error {
+		parsed, err := url.Parse(authURL)
+		assert.NoError(suite.T(), err)
+		query := parsed.Query()
+		assert.Equal(suite.T(), "S256", query.Get("code_challenge_method"))
+		suite.challenge = query.Get("code_challenge")
+
+		// play the browser following the redirect after sign in
+		go http.Get(query.Get("redirect_uri") + "?code=code&state=" + url.QueryEscape(query.Get("state")))
+		return nil
+	})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "access", token.AccessToken)
+	assert.Equal(suite.T(), "jane@example.com", TokenUsername(token))
+}
+
+func (suite *DelegatedTestSuite) TestInteractiveLoginIgnoresStrayRequests() {
+	token, err := InteractiveLogin(context.Background(), suite.config, func(authURL string) error {
+		parsed, err := url.Parse(authURL)
+		assert.NoError(suite.T(), err)
+		query := parsed.Query()
+		suite.challenge = query.Get("code_challenge")
+		redirect := query.Get("redirect_uri")
+
+		// requests arriving before the redirect do not end the login
+		for _, stray := range []string{redirect + "/favicon.ico", redirect, redirect + "?state=probe"} {
+			response, err := http.Get(stray)
+			assert.NoError(suite.T(), err)
+			response.Body.Close()
+			assert.Equal(suite.T(), http.StatusNotFound, response.StatusCode)
+		}
+		go http.Get(redirect + "?code=code&state=" + url.QueryEscape(query.Get("state")))
+		return nil
+	})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "access", token.AccessToken)
+}
+
+func (suite *DelegatedTestSuite) TestAccounts() {
+	dir, err := ioutil.TempDir("", "msauth")
+	assert.NoError(suite.T(), err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "msgraph", "accounts.json")
+
+	accounts, err := LoadAccounts(path)
+	assert.NoError(suite.T(), err)
+	accounts.Add(Account{Tenant: "tenant", Username: "jane@example.com", Token: &oauth2.Token{RefreshToken: "1"}})
+	accounts.Add(Account{Tenant: "tenant", Username: "joe@example.com", Token: &oauth2.Token{RefreshToken: "2"}})
+	assert.NoError(suite.T(), accounts.Save())
+
+	accounts, err = LoadAccounts(path)
+	assert.NoError(suite.T(), err)
+	current, found := accounts.Find("tenant", "")
+	assert.True(suite.T(), found)
+	assert.Equal(suite.T(), "joe@example.com", current.Username)
+
+	accounts.Remove("tenant", "joe@example.com")
+	_, found = accounts.Find("tenant", "")
+	assert.False(suite.T(), found)
+	jane, found := accounts.Find("tenant", "Jane@example.com")
+	assert.True(suite.T(), found)
+	assert.Equal(suite.T(), "1", jane.Token.RefreshToken)
+}
+
+func (suite *DelegatedTestSuite) TestConcurrentRefreshes() {
+	dir, err := ioutil.TempDir("", "msauth")
+	assert.NoError(suite.T(), err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "accounts.json")
+
+	accounts, err := LoadAccounts(path)
+	assert.NoError(suite.T(), err)
+	jane := Account{Tenant: "tenant", Username: "jane@example.com", Token: &oauth2.Token{RefreshToken: "1"}}
+	joe := Account{Tenant: "tenant", Username: "joe@example.com", Token: &oauth2.Token{RefreshToken: "2"}}
+	accounts.Add(jane)
+	accounts.Add(joe)
+	assert.NoError(suite.T(), accounts.Save())
+
+	// two processes that loaded the cache before either refreshed its account
+	first, err := LoadAccounts(path)
+	assert.NoError(suite.T(), err)
+	second, err := LoadAccounts(path)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), first.SaveToken(jane, &oauth2.Token{RefreshToken: "3"}))
+	assert.NoError(suite.T(), second.SaveToken(joe, &oauth2.Token{RefreshToken: "4"}))
+
+	saved, err := LoadAccounts(path)
+	assert.NoError(suite.T(), err)
+	refreshed, _ := saved.Find("tenant", "jane@example.com")
+	assert.Equal(suite.T(), "3", refreshed.Token.RefreshToken, "the refresh of the other process is kept")
+	refreshed, _ = saved.Find("tenant", "joe@example.com")
+	assert.Equal(suite.T(), "4", refreshed.Token.RefreshToken)
+	refreshed, _ = second.Find("tenant", "joe@example.com")
+	assert.Equal(suite.T(), "4", refreshed.Token.RefreshToken)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "accounts.json.tmp*"))
+	assert.Empty(suite.T(), files)
+}
+
+func TestDelegatedTestSuite(t *testing.T) {
+	suite.Run(t, new(DelegatedTestSuite))
+}
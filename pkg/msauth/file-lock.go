@@ -0,0 +1,45 @@
+package msauth
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockTimeout how long to wait for another process holding a lock
+const lockTimeout = 30 * time.Second
+
+// lockFile acquires an exclusive advisory lock on the file at path, created when missing, the returned
+// function releases it. The operating system releases the lock of a process that exits without doing so, so
+// a crashed process never leaves a lock behind. The file itself is kept, removing it would let a waiter lock
+// the removed file while another process locks its replacement.
+func lockFile(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		locked, err := tryLock(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		if locked {
+			return func() {
+				unlock(file)
+				file.Close()
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			file.Close()
+			return nil, fmt.Errorf("timed out waiting for lock %s", path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,141 @@
+package msauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// DefaultManagedIdentityEndpoint the Azure instance metadata service (IMDS) token endpoint
+const DefaultManagedIdentityEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+// managedIdentityDialTimeout fails fast when no metadata service is listening
+const managedIdentityDialTimeout = time.Second
+
+// NewWorkloadIdentityTokenSource Returns a TokenSource exchanging the federated token in tokenFile (for
+// example a Kubernetes service account token) for an access token. The file is re-read on every request
+// as it is rotated by the platform.
+func NewWorkloadIdentityTokenSource(ctx context.Context, tokenURL string, clientID string, scopes []string, tokenFile string) oauth2.TokenSource {
+	source := assertionTokenSource{
+		ctx: ctx,
+		config: clientcredentials.Config{
+			ClientID: clientID,
+			Scopes:   scopes,
+			TokenURL: tokenURL,
+		},
+		assertion: func() (string, error) {
+			data, err := ioutil.ReadFile(tokenFile)
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(string(data)), nil
+		},
+	}
+	return oauth2.ReuseTokenSource(nil, source)
+}
+
+// managedIdentityTokenSource requests tokens from an IMDS style managed identity endpoint
+type managedIdentityTokenSource struct {
+	ctx      context.Context
+	client   *http.Client
+	endpoint string
+	header   string
+	resource string
+	clientID string
+}
+
+// managedIdentityResponse the managed identity endpoint response, expiry values are sent as strings
+type managedIdentityResponse struct {
+	AccessToken string          `json:"access_token"`
+	TokenType   string          `json:"token_type"`
+	ExpiresIn   json.RawMessage `json:"expires_in"`
+	ExpiresOn   json.RawMessage `json:"expires_on"`
+}
+
+// NewManagedIdentityTokenSource Returns a TokenSource requesting tokens for resource from the managed identity
+// endpoint. header is the App Service X-IDENTITY-HEADER secret, empty for IMDS. clientID selects a user
+// assigned identity, empty for the system assigned identity.
+func NewManagedIdentityTokenSource(ctx context.Context, endpoint string, header string, resource string, clientID string) oauth2.TokenSource {
+	dialer := &net.Dialer{Timeout: managedIdentityDialTimeout}
+	source := managedIdentityTokenSource{
+		ctx: ctx,
+		client: &http.Client{
+			Transport: &http.Transport{Proxy: nil, DialContext: dialer.DialContext},
+			Timeout:   30 * time.Second,
+		},
+		endpoint: endpoint,
+		header:   header,
+		resource: resource,
+		clientID: clientID,
+	}
+	return oauth2.ReuseTokenSource(nil, source)
+}
+
+func (s managedIdentityTokenSource) Token() (*oauth2.Token, error) {
+	params := url.Values{"resource": []string{s.resource}}
+	if s.header != "" {
+		params.Set("api-version", "2019-08-01")
+	} else {
+		params.Set("api-version", "2018-02-01")
+	}
+	if s.clientID != "" {
+		params.Set("client_id", s.clientID)
+	}
+
+	req, err := http.NewRequest("GET", s.endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.header != "" {
+		req.Header.Set("X-IDENTITY-HEADER", s.header)
+	} else {
+		req.Header.Set("Metadata", "true")
+	}
+
+	resp, err := s.client.Do(req.WithContext(s.ctx))
+	if err != nil {
+		return nil, unavailable("managed identity endpoint not reachable: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusNotFound:
+		// IMDS answers 400 when the machine has no (matching) identity assigned
+		return nil, unavailable("no managed identity: %s: %s", resp.Status, body)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("managed identity token request failed: %s: %s", resp.Status, body)
+	}
+
+	var response managedIdentityResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	token := &oauth2.Token{AccessToken: response.AccessToken, TokenType: response.TokenType}
+	if expiresOn, ok := parseSeconds(response.ExpiresOn); ok {
+		token.Expiry = time.Unix(expiresOn, 0)
+	} else if expiresIn, ok := parseSeconds(response.ExpiresIn); ok {
+		token.Expiry = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// parseSeconds parses a number of seconds sent either as a JSON number or a string
+func parseSeconds(raw json.RawMessage) (int64, bool) {
+	value, err := strconv.ParseInt(strings.Trim(string(raw), `"`), 10, 64)
+	return value, err == nil
+}
@@ -2,22 +2,73 @@ package msauth
 
 import (
 	"context"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
-	"golang.org/x/oauth2/microsoft"
 	"net/http"
+	"strings"
+	"westpac.co.nz/msgraph/pkg/helpers"
 )
 
+// DefaultScopes the scopes requested for application (client credential) tokens
+var DefaultScopes = []string{"https://graph.microsoft.com/.default"}
+
+// DefaultAuthorityHost the public cloud login host
+const DefaultAuthorityHost = "https://login.microsoftonline.com/"
+
+// AzureADEndpoint Returns the v2 OAuth2 endpoints of tenant on the authority (login) host
+func AzureADEndpoint(authorityHost string, tenant string) oauth2.Endpoint {
+	if authorityHost == "" {
+		authorityHost = DefaultAuthorityHost
+	}
+	base := strings.TrimSuffix(authorityHost, "/") + "/" + tenant + "/oauth2/v2.0"
+	return oauth2.Endpoint{
+		AuthURL:  base + "/authorize",
+		TokenURL: base + "/token",
+	}
+}
+
 //GetOAuth2Client Returns a OAuth2 http.Client instance that will inject Bearer token
 func GetOAuth2Client(tenant string, clientID string, clientSecret string) *http.Client {
 
-	microsoftEndpoints := microsoft.AzureADEndpoint(tenant)
+	return oauth2.NewClient(context.Background(), GetClientSecretTokenSource(tenant, clientID, clientSecret))
+
+}
+
+//GetClientSecretTokenSource Returns a TokenSource requesting client credential tokens with a client secret
+func GetClientSecretTokenSource(tenant string, clientID string, clientSecret string) oauth2.TokenSource {
+
+	microsoftEndpoints := AzureADEndpoint(DefaultAuthorityHost, tenant)
+	return newClientSecretTokenSource(context.Background(), microsoftEndpoints.TokenURL, clientID, clientSecret, DefaultScopes)
+
+}
+
+func newClientSecretTokenSource(ctx context.Context, tokenURL string, clientID string, clientSecret string, scopes []string) oauth2.TokenSource {
+
 	conf := &clientcredentials.Config{
 		ClientID:     clientID,
 		ClientSecret: clientSecret,
-		Scopes:       []string{"https://graph.microsoft.com/.default"},
-		TokenURL:     microsoftEndpoints.TokenURL,
+		Scopes:       scopes,
+		TokenURL:     tokenURL,
 	}
 
-	return conf.Client(context.Background())
+	return conf.TokenSource(ctx)
+
+}
+
+//GetOAuth2CertificateClient Returns a OAuth2 http.Client instance authenticating with a certificate signed client assertion
+func GetOAuth2CertificateClient(tenant string, clientID string, certFile string, keyFile string, password string) *http.Client {
+
+	return oauth2.NewClient(context.Background(), GetCertificateTokenSource(tenant, clientID, certFile, keyFile, password))
+
+}
+
+//GetCertificateTokenSource Returns a TokenSource requesting client credential tokens with a certificate signed client assertion
+func GetCertificateTokenSource(tenant string, clientID string, certFile string, keyFile string, password string) oauth2.TokenSource {
+
+	certificate, err := LoadCertificate(certFile, keyFile, password)
+	helpers.ErrorHandlerFatal("Could not load client certificate ", err)
+
+	microsoftEndpoints := AzureADEndpoint(DefaultAuthorityHost, tenant)
+	return NewCertificateTokenSource(context.Background(), microsoftEndpoints.TokenURL, clientID, DefaultScopes, certificate)
 
 }
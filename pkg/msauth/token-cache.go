@@ -0,0 +1,258 @@
+package msauth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/oauth2"
+)
+
+// TokenCacheKeyEnv the environment variable holding the token cache passphrase
+const TokenCacheKeyEnv = "MSGRAPH_TOKEN_CACHE_KEY"
+
+// expiryMargin cached tokens expiring within the margin are not handed out
+const expiryMargin = time.Minute
+
+// TokenCache an on disk cache of access tokens shared by concurrent processes. The file is
+// encrypted with AES-GCM using a key derived from a passphrase with scrypt.
+type TokenCache struct {
+	path       string
+	passphrase []byte
+
+	salt []byte
+	key  []byte
+}
+
+// tokenCacheFile the encrypted file layout
+type tokenCacheFile struct {
+	Salt  []byte `json:"salt"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+// cachedToken the persisted part of an oauth2.Token
+type cachedToken struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	Expiry      time.Time `json:"expiry"`
+}
+
+// DefaultTokenCachePath the token cache in the user cache directory
+func DefaultTokenCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "msgraph", "tokens.enc")
+}
+
+// ReadPassphraseFile reads a passphrase from a file, surrounding white space is ignored
+func ReadPassphraseFile(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	passphrase := strings.TrimSpace(string(data))
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase file %s is empty", path)
+	}
+	return passphrase, nil
+}
+
+// NewTokenCache Returns the token cache at path encrypted with passphrase
+func NewTokenCache(path string, passphrase string) *TokenCache {
+	return &TokenCache{path: path, passphrase: []byte(passphrase)}
+}
+
+// TokenCacheKey the cache key of the tokens of clientID in tenant for scopes
+func TokenCacheKey(tenant string, clientID string, scopes []string) string {
+	sorted := append([]string{}, scopes...)
+	sort.Strings(sorted)
+	hash := sha256.Sum256([]byte(strings.ToLower(tenant) + "|" + strings.ToLower(clientID) + "|" + strings.Join(sorted, " ")))
+	return hex.EncodeToString(hash[:])
+}
+
+// deriveKey derives the encryption key for salt, the key is kept for the lifetime of the cache
+func (c *TokenCache) deriveKey(salt []byte) ([]byte, error) {
+	if c.key != nil && string(salt) == string(c.salt) {
+		return c.key, nil
+	}
+	key, err := scrypt.Key(c.passphrase, salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	c.salt, c.key = salt, key
+	return key, nil
+}
+
+// load decrypts the cache file, a missing file is an empty cache
+func (c *TokenCache) load() (map[string]cachedToken, error) {
+	tokens := map[string]cachedToken{}
+
+	data, err := ioutil.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file tokenCacheFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	key, err := c.deriveKey(file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, errors.New("token cache cannot be decrypted, wrong passphrase?")
+	}
+	if err := json.Unmarshal(plain, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// save encrypts and atomically replaces the cache file
+func (c *TokenCache) save(tokens map[string]cachedToken) error {
+	plain, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+
+	salt := c.salt
+	if salt == nil {
+		salt = make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+	}
+	key, err := c.deriveKey(salt)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(tokenCacheFile{Salt: salt, Nonce: nonce, Data: gcm.Seal(nil, nonce, plain, nil)})
+	if err != nil {
+		return err
+	}
+
+	return replaceFile(c.path, data)
+}
+
+// replaceFile atomically replaces the file at path with data, readable by the current user only. Readers
+// see the previous or the new content, never a partly written file.
+func replaceFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	temp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// lock acquires the cross process lock file next to the cache, the returned function releases it
+func (c *TokenCache) lock() (func(), error) {
+	return lockFile(c.path + ".lock")
+}
+
+// Token returns the cached token for key, or fetches one from source and caches it. The cache stays
+// locked while fetching so concurrent processes wait for the token instead of all requesting one.
+func (c *TokenCache) Token(key string, source oauth2.TokenSource) (*oauth2.Token, error) {
+	unlock, err := c.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	tokens, err := c.load()
+	if err != nil {
+		log.Warn("Ignoring unreadable token cache: ", err)
+		tokens = map[string]cachedToken{}
+		c.salt, c.key = nil, nil
+	}
+
+	if cached, found := tokens[key]; found && time.Until(cached.Expiry) > expiryMargin {
+		log.Debug("Using cached token")
+		return &oauth2.Token{AccessToken: cached.AccessToken, TokenType: cached.TokenType, Expiry: cached.Expiry}, nil
+	}
+
+	token, err := source.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	// drop expired tokens of other clients while rewriting the file
+	for cachedKey, cached := range tokens {
+		if time.Now().After(cached.Expiry) {
+			delete(tokens, cachedKey)
+		}
+	}
+	tokens[key] = cachedToken{AccessToken: token.AccessToken, TokenType: token.TokenType, Expiry: token.Expiry}
+	if err := c.save(tokens); err != nil {
+		log.Warn("Could not write token cache: ", err)
+	}
+	return token, nil
+}
+
+// cachedTokenSource a TokenSource backed by a TokenCache entry
+type cachedTokenSource struct {
+	cache  *TokenCache
+	key    string
+	source oauth2.TokenSource
+}
+
+func (s cachedTokenSource) Token() (*oauth2.Token, error) {
+	return s.cache.Token(s.key, s.source)
+}
+
+// NewCachedTokenSource Returns a TokenSource that serves the tokens of source from the cache entry key
+func NewCachedTokenSource(cache *TokenCache, key string, source oauth2.TokenSource) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, cachedTokenSource{cache: cache, key: key, source: source})
+}
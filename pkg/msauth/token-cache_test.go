@@ -0,0 +1,146 @@
+package msauth
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/oauth2"
+)
+
+// countingTokenSource hands out numbered tokens expiring after lifetime
+type countingTokenSource struct {
+	mutex    sync.Mutex
+	count    int
+	lifetime time.Duration
+}
+
+func (s *countingTokenSource) Token() (*oauth2.Token, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.count++
+	return &oauth2.Token{AccessToken: fmt.Sprintf("access-token-%d", s.count), TokenType: "Bearer", Expiry: time.Now().Add(s.lifetime)}, nil
+}
+
+type TokenCacheTestSuite struct {
+	suite.Suite
+	dir  string
+	path string
+}
+
+func (suite *TokenCacheTestSuite) SetupTest() {
+	var err error
+	suite.dir, err = ioutil.TempDir("", "msauth")
+	assert.NoError(suite.T(), err)
+	suite.path = filepath.Join(suite.dir, "tokens.enc")
+}
+
+func (suite *TokenCacheTestSuite) TearDownTest() {
+	os.RemoveAll(suite.dir)
+}
+
+func (suite *TokenCacheTestSuite) TestCachedAcrossInstances() {
+	source := &countingTokenSource{lifetime: time.Hour}
+	key := TokenCacheKey("tenant", "client", DefaultScopes)
+
+	token, err := NewTokenCache(suite.path, "secret").Token(key, source)
+	assert.NoError(suite.T(), err)
+	cached, err := NewTokenCache(suite.path, "secret").Token(key, source)
+	assert.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), token.AccessToken, cached.AccessToken)
+	assert.Equal(suite.T(), 1, source.count)
+
+	// a different client has its own entry
+	_, err = NewTokenCache(suite.path, "secret").Token(TokenCacheKey("tenant", "other", DefaultScopes), source)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, source.count)
+}
+
+func (suite *TokenCacheTestSuite) TestEncrypted() {
+	source := &countingTokenSource{lifetime: time.Hour}
+	token, err := NewTokenCache(suite.path, "secret").Token("key", source)
+	assert.NoError(suite.T(), err)
+
+	data, err := ioutil.ReadFile(suite.path)
+	assert.NoError(suite.T(), err)
+	assert.NotContains(suite.T(), string(data), token.AccessToken)
+
+	// a wrong passphrase cannot read the cache and fetches a new token
+	_, err = NewTokenCache(suite.path, "wrong").Token("key", source)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, source.count)
+}
+
+func (suite *TokenCacheTestSuite) TestExpiry() {
+	source := &countingTokenSource{lifetime: expiryMargin / 2}
+	cache := NewTokenCache(suite.path, "secret")
+
+	_, err := cache.Token("key", source)
+	assert.NoError(suite.T(), err)
+	_, err = cache.Token("key", source)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, source.count)
+}
+
+func (suite *TokenCacheTestSuite) TestConcurrentProcesses() {
+	source := &countingTokenSource{lifetime: time.Hour}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := NewTokenCache(suite.path, "secret").Token("key", source)
+			assert.NoError(suite.T(), err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(suite.T(), 1, source.count)
+}
+
+func (suite *TokenCacheTestSuite) TestLeftBehindLockFile() {
+	source := &countingTokenSource{lifetime: time.Hour}
+
+	// the lock file of a process that exited, its lock was released with it
+	assert.NoError(suite.T(), ioutil.WriteFile(suite.path+".lock", []byte("1"), 0600))
+
+	start := time.Now()
+	_, err := NewTokenCache(suite.path, "secret").Token("key", source)
+	assert.NoError(suite.T(), err)
+	assert.Less(suite.T(), int64(time.Since(start)), int64(lockTimeout/2))
+}
+
+func (suite *TokenCacheTestSuite) TestWaitsForLockHolder() {
+	source := &countingTokenSource{lifetime: time.Hour}
+
+	// held however long the holder takes, e.g. fetching a token
+	release, err := NewTokenCache(suite.path, "secret").lock()
+	assert.NoError(suite.T(), err)
+
+	done := make(chan error)
+	go func() {
+		_, err := NewTokenCache(suite.path, "secret").Token("key", source)
+		done <- err
+	}()
+	select {
+	case <-done:
+		suite.T().Fatal("the lock was taken while held")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	release()
+	assert.NoError(suite.T(), <-done)
+	assert.Equal(suite.T(), 1, source.count)
+}
+
+func TestTokenCacheTestSuite(t *testing.T) {
+	suite.Run(t, new(TokenCacheTestSuite))
+}
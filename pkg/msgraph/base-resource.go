@@ -2,24 +2,44 @@ package msgraph
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/urfave/cli/v2"
 	"io"
 	"io/ioutil"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
-	"westpac.co.nz/msgraph/pkg/helpers"
 )
 
 // BaseResourceAPI GraphBaseResourceAPI
 type BaseResource struct {
 	UserAgent string
-	Version   string
+	// Version the API version prefixed to every request path, DefaultVersion when empty
+	Version string
+	// BaseURL the Graph API endpoint, AzureGraphAPIURL when empty
+	BaseURL string
 
 	HTTPClient *http.Client
+	// RetryPolicy how throttled requests are retried, DefaultRetryPolicy when nil
+	RetryPolicy *RetryPolicy
+	// RequestTimeout limits every attempt of a request, no limit when zero
+	RequestTimeout time.Duration
+	// RateLimiter paces every attempt of a request, unlimited when nil. It is told about every response
+	// when it implements ResponseObserver.
+	RateLimiter RateLimiter
+	// Logger logs paging and retries, the standard logger when nil
+	Logger *log.Logger
+}
+
+// RateLimiter paces the requests sent to the API
+type RateLimiter interface {
+	// Wait blocks until the next request may be sent or ctx is done
+	Wait(ctx context.Context) error
 }
 
 // {
@@ -39,90 +59,307 @@ type GraphAPIErrorResponse struct {
 	Error GraphAPIErrorObject `json:"error"`
 }
 
-//GraphAPIErrorObject GraphAPIErrorObject
+// GraphAPIErrorObject GraphAPIErrorObject
 type GraphAPIErrorObject struct {
 	Code       string                   `json:"code"`
 	Message    string                   `json:"message"`
 	InnerError GraphAPIInnerErrorObject `json:"innerError"`
 }
 
-//GraphAPIInnerErrorObject GraphAPIInnerErrorObject
+// GraphAPIInnerErrorObject GraphAPIInnerErrorObject
 type GraphAPIInnerErrorObject struct {
 	Date            string `json:"date"`
 	RequestID       string `json:"request-id"`
 	ClientRequestID string `json:"client-request-id"`
 }
 
+// GraphAPIError an error response of the Graph API
+type GraphAPIError struct {
+	StatusCode int
+	GraphAPIErrorObject
+}
+
+func newGraphAPIError(statusCode int, body []byte) *GraphAPIError {
+	var response GraphAPIErrorResponse
+	if err := json.Unmarshal(body, &response); err != nil || response.Error.Code == "" {
+		response.Error.Code = http.StatusText(statusCode)
+		response.Error.Message = string(body)
+	}
+	return &GraphAPIError{StatusCode: statusCode, GraphAPIErrorObject: response.Error}
+}
+
+func (e *GraphAPIError) Error() string {
+	message := fmt.Sprintf("Error from GraphAPI: %d %s: %s", e.StatusCode, e.Code, e.Message)
+	if e.InnerError.RequestID != "" {
+		message += fmt.Sprintf(" (request-id %s)", e.InnerError.RequestID)
+	}
+	return message
+}
+
 // AzureGraphAPIURL the graph API endpoint
 const AzureGraphAPIURL = "https://graph.microsoft.com/"
 
-func (b BaseResource) List(r ResourceAPI, context cli.Context, args cli.Args) []Resource {
-	path := r.CreateRequestPath(context, args)
-	params := r.CreateQueryParams(context, args)
+// DefaultUserAgent the User-Agent header sent when BaseResource.UserAgent is empty
+const DefaultUserAgent = "westpac-msgraph-go/1.0"
+
+const (
+	// VersionV1 the generally available API
+	VersionV1 = "v1.0"
+	// VersionBeta the preview API, includes properties not (yet) in v1.0
+	VersionBeta = "beta"
+	// DefaultVersion the API version used when BaseResource.Version is empty
+	DefaultVersion = VersionV1
+)
+
+// Versions the supported API versions
+var Versions = []string{VersionV1, VersionBeta}
+
+// GraphAPIPageResponse paging information of a collection response
+type GraphAPIPageResponse struct {
+	NextLink string `json:"@odata.nextLink"`
+}
+
+// List retrieves every page of the collection of r. When ctx is cancelled the resources retrieved so far
+// are returned with the error.
+func (b BaseResource) List(ctx context.Context, r ResourceAPI, c cli.Context, args cli.Args) ([]Resource, error) {
+	path := r.CreateRequestPath(c, args)
+	params := r.CreateQueryParams(c, args)
+
+	return b.ListPath(ctx, r, path, params)
+}
+
+// ListPath retrieves every page of the collection at path and converts it using r. On error the
+// resources of the pages retrieved so far are returned with it.
+func (b BaseResource) ListPath(ctx context.Context, r ResourceAPI, path string, params url.Values) ([]Resource, error) {
+	var resources []Resource
+	err := b.pages(ctx, path, params, func(body []byte) error {
+		resources = append(resources, r.ConvertToResourceSlice(body)...)
+		return nil
+	})
+	return resources, err
+}
+
+// pages calls page with the body of every page of the collection at path, until the last page or an error
+func (b BaseResource) pages(ctx context.Context, path string, params url.Values, page func(body []byte) error) error {
+	request, err := b.newRequest("GET", path, params, nil)
+	if err != nil {
+		return err
+	}
+	return b.pagesFrom(ctx, request, page)
+}
+
+// pagesFrom calls page with the body of request and of every page following it
+func (b BaseResource) pagesFrom(ctx context.Context, request *http.Request, page func(body []byte) error) error {
+	var err error
+	for err == nil && request != nil {
+		var body []byte
+		if body, err = b.do(ctx, request); err != nil {
+			break
+		}
+		if err = page(body); err != nil {
+			break
+		}
+		request, err = b.nextPage(body)
+	}
+	return err
+}
+
+// nextPage returns the request for the page following body, nil when body is the last page
+func (b BaseResource) nextPage(body []byte) (*http.Request, error) {
+	var page GraphAPIPageResponse
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, fmt.Errorf("JSON unmarshalling of response body failed: %v", err)
+	}
+
+	if page.NextLink == "" {
+		return nil, nil
+	}
+	b.logger().Debug("Fetching next page:", page.NextLink)
+
+	return b.newRequestURL("GET", page.NextLink, nil)
+}
+
+// Create POSTs body to the resource path and returns the created resource
+func (b BaseResource) Create(ctx context.Context, r ResourceAPI, c cli.Context, args cli.Args, body interface{}) (Resource, error) {
+	response, err := b.Request(ctx, "POST", r.CreateRequestPath(c, args), nil, body)
+	if err != nil {
+		return nil, err
+	}
+	return r.ConvertToResource(response), nil
+}
+
+// Update PATCHes the object at path with body
+func (b BaseResource) Update(ctx context.Context, path string, body interface{}) error {
+	_, err := b.Request(ctx, "PATCH", path, nil, body)
+	return err
+}
+
+// Delete removes the object at path
+func (b BaseResource) Delete(ctx context.Context, path string) error {
+	_, err := b.Request(ctx, "DELETE", path, nil, nil)
+	return err
+}
+
+// Request executes an arbitrary request for path, relative to the API version, and returns the raw response body
+func (b BaseResource) Request(ctx context.Context, method, path string, queryParams url.Values, body interface{}) ([]byte, error) {
+	request, err := b.newRequest(method, path, queryParams, body)
+	if err != nil {
+		return nil, err
+	}
+	return b.do(ctx, request)
+}
+
+// newRequest creates a request for path, relative to the API version. Ids and user principal names are put in
+// path unescaped, they are encoded once here.
+func (b BaseResource) newRequest(method, path string, queryParams url.Values, body interface{}) (*http.Request, error) {
+
+	baseURL, err := url.Parse(b.baseURL())
+	if err != nil {
+		return nil, fmt.Errorf("invalid graph API URL: %v", err)
+	}
+	u := below(baseURL, b.versionedPath(path), queryParams.Encode())
+
+	return b.newRequestURL(method, u, body)
+}
 
-	request := b.newRequest("GET", path, params, nil)
-	body := b.do(request)
+// below the URL of path below the base URL. The path of the base URL, e.g. of a proxy, is kept and path is
+// appended as is, without resolving dot segments.
+func below(base *url.URL, path string, rawQuery string) string {
+	u := *base
+	u.Path = strings.TrimSuffix(base.Path, "/") + path
+	u.RawPath = ""
+	u.RawQuery = rawQuery
+	u.Fragment = ""
+	return u.String()
+}
 
-	return r.ConvertToResourceSlice(body)
+func (b BaseResource) versionedPath(path string) string {
+	version := b.Version
+	if version == "" {
+		version = DefaultVersion
+	}
+	return "/" + version + path
 }
 
-func (b BaseResource) newRequest(method, path string, queryParams url.Values, body interface{}) *http.Request {
+func (b BaseResource) baseURL() string {
+	if b.BaseURL == "" {
+		return AzureGraphAPIURL
+	}
+	return b.BaseURL
+}
 
-	rel := &url.URL{Path: path, RawQuery: queryParams.Encode()}
-	baseURL, _ := url.Parse(AzureGraphAPIURL)
-	u := baseURL.ResolveReference(rel)
+func (b BaseResource) newRequestURL(method, u string, body interface{}) (*http.Request, error) {
 
 	var buf io.ReadWriter
 	if body != nil {
 		buf = new(bytes.Buffer)
-		err := json.NewEncoder(buf).Encode(body)
-		helpers.ErrorHandlerFatal("JSON encoding for request body failed:", err)
+		if err := json.NewEncoder(buf).Encode(body); err != nil {
+			return nil, fmt.Errorf("JSON encoding for request body failed: %v", err)
+		}
 	}
 
-	req, err := http.NewRequest(method, u.String(), buf)
-	helpers.ErrorHandlerFatal("Request construction failed:", err)
+	req, err := http.NewRequest(method, u, buf)
+	if err != nil {
+		return nil, fmt.Errorf("request construction failed: %v", err)
+	}
 
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", b.UserAgent)
+	userAgent := b.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
 
-	return req
+	return req, nil
 }
 
-func (b BaseResource) do(req *http.Request) []byte {
-
-	if log.GetLevel() == log.TraceLevel {
-		dump, err := httputil.DumpRequestOut(req, true)
-		helpers.ErrorHandlerFatal("Request dump failed:", err)
-		log.Tracef("REQUEST: %s", string(dump))
+// do executes req and returns the response body, retrying it according to the retry policy while the API
+// throttles it. Responses other than 2xx are returned as a *GraphAPIError.
+func (b BaseResource) do(ctx context.Context, req *http.Request) ([]byte, error) {
+	policy := DefaultRetryPolicy
+	if b.RetryPolicy != nil {
+		policy = *b.RetryPolicy
 	}
 
-	resp, err := b.HTTPClient.Do(req)
-	helpers.ErrorHandlerFatal("Request execution failed:", err)
+	// requests and responses are logged, redacted, by the httplog transport of the HTTP client
+	for attempt := 0; ; attempt++ {
+		resp, body, err := b.roundTrip(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		b.logger().Trace("Status Response:", resp.Status)
+		if observer, ok := b.RateLimiter.(ResponseObserver); ok {
+			observer.Observe(resp)
+		}
 
-	if log.GetLevel() == log.TraceLevel {
-		dump, err := httputil.DumpResponse(resp, true)
-		helpers.ErrorHandlerFatal("Request dump failed:", err)
-		log.Tracef("RESPONSE: %q", dump)
+		if attempt < policy.MaxRetries && policy.Retryable(resp.StatusCode) {
+			delay := policy.Delay(attempt, resp)
+			b.logger().Debugf("%s, retrying in %s", resp.Status, delay)
+			if err := sleep(ctx, delay); err != nil {
+				return nil, err
+			}
+			if req.GetBody != nil {
+				if req.Body, err = req.GetBody(); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, newGraphAPIError(resp.StatusCode, body)
+		}
+		return body, nil
 	}
+}
 
-	log.Trace("Status Response:", resp.Status)
+// roundTrip sends req once, when the rate limiter allows it and within the request timeout, and reads the
+// response body
+func (b BaseResource) roundTrip(ctx context.Context, req *http.Request) (*http.Response, []byte, error) {
+	if b.RateLimiter != nil {
+		if err := b.RateLimiter.Wait(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+	if b.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.RequestTimeout)
+		defer cancel()
+	}
 
+	resp, err := b.HTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
 	defer resp.Body.Close()
+
 	body, err := ioutil.ReadAll(resp.Body)
-	helpers.ErrorHandlerFatal("Reading response body failed", err)
-	log.Trace(string(body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading response body failed: %v", err)
+	}
+	return resp, body, nil
+}
 
-	if resp.StatusCode != 200 {
-		var graphErr GraphAPIErrorResponse
-		err = json.Unmarshal(body, &graphErr)
-		helpers.ErrorHandlerFatal("JSON unmarshalling of response body failed:", err)
+func (b BaseResource) logger() *log.Logger {
+	if b.Logger == nil {
+		return log.StandardLogger()
+	}
+	return b.Logger
+}
 
-		log.Fatalf("Error from GraphAPI: %+v", graphErr.Error)
+// sleep waits for delay, or until ctx is done
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	return body
 }
 
 func CreateURLFilterParams(criteria *Criteria) url.Values {
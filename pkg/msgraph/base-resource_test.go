@@ -0,0 +1,25 @@
+package msgraph
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRequestBasePath(t *testing.T) {
+	for baseURL, expected := range map[string]string{
+		"https://graph.microsoft.com":         "https://graph.microsoft.com/v1.0/users/megan_fabrikam.com%23EXT%23@contoso.com?%24top=5",
+		"https://graph.microsoft.com/":        "https://graph.microsoft.com/v1.0/users/megan_fabrikam.com%23EXT%23@contoso.com?%24top=5",
+		"https://proxy.example/graph":         "https://proxy.example/graph/v1.0/users/megan_fabrikam.com%23EXT%23@contoso.com?%24top=5",
+		"https://proxy.example/graph/?ignore": "https://proxy.example/graph/v1.0/users/megan_fabrikam.com%23EXT%23@contoso.com?%24top=5",
+	} {
+		b := BaseResource{BaseURL: baseURL}
+
+		request, err := b.newRequest("GET", "/users/megan_fabrikam.com#EXT#@contoso.com", url.Values{"$top": {"5"}}, nil)
+
+		require.NoError(t, err, baseURL)
+		assert.Equal(t, expected, request.URL.String(), baseURL)
+	}
+}
@@ -0,0 +1,114 @@
+package msgraph
+
+import (
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+	"westpac.co.nz/msgraph/pkg/httplog"
+	"westpac.co.nz/msgraph/pkg/redact"
+)
+
+// Client a Graph API client for Go programs. The embedded BaseResource sends arbitrary requests, the
+// services the common operations on users, groups and applications.
+type Client struct {
+	BaseResource
+
+	Users        *UsersService
+	Groups       *GroupsService
+	Applications *ApplicationsService
+}
+
+// Option configures a Client
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	base       BaseResource
+	source     oauth2.TokenSource
+	transport  http.RoundTripper
+	properties []string
+	scopes     []redact.Scope
+}
+
+// WithTokenSource authenticates every request with a bearer token of source. Tokens are reused until they expire.
+func WithTokenSource(source oauth2.TokenSource) Option {
+	return func(o *clientOptions) { o.source = source }
+}
+
+// WithBaseURL sends the requests to url instead of AzureGraphAPIURL, e.g. a national cloud or a test server
+func WithBaseURL(url string) Option {
+	return func(o *clientOptions) { o.base.BaseURL = url }
+}
+
+// WithVersion selects the API version, VersionV1 or VersionBeta
+func WithVersion(version string) Option {
+	return func(o *clientOptions) { o.base.Version = version }
+}
+
+// WithUserAgent sets the User-Agent header, DefaultUserAgent otherwise
+func WithUserAgent(userAgent string) Option {
+	return func(o *clientOptions) { o.base.UserAgent = userAgent }
+}
+
+// WithRetryPolicy retries throttled requests according to policy instead of DefaultRetryPolicy
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *clientOptions) { o.base.RetryPolicy = &policy }
+}
+
+// WithRequestTimeout limits every attempt of a request to timeout
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(o *clientOptions) { o.base.RequestTimeout = timeout }
+}
+
+// WithRateLimiter paces the requests with limiter, which may be shared with other clients
+func WithRateLimiter(limiter RateLimiter) Option {
+	return func(o *clientOptions) { o.base.RateLimiter = limiter }
+}
+
+// WithLogger logs requests, paging and retries to logger instead of the standard logger
+func WithLogger(logger *log.Logger) Option {
+	return func(o *clientOptions) { o.base.Logger = logger }
+}
+
+// WithRedactedProperties redacts the JSON properties named in addition to redact.DefaultProperties from
+// the request and response bodies logged at trace level
+func WithRedactedProperties(names ...string) Option {
+	return func(o *clientOptions) { o.properties = append(o.properties, names...) }
+}
+
+// WithRedactionScopes redacts the properties of each scope from the objects of its type only, see redact.Scope
+func WithRedactionScopes(scopes ...redact.Scope) Option {
+	return func(o *clientOptions) { o.scopes = append(o.scopes, scopes...) }
+}
+
+// WithTransport sends the requests with transport instead of http.DefaultTransport
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *clientOptions) { o.transport = transport }
+}
+
+// NewClient creates a client configured by options. Without WithTokenSource requests are sent without
+// authentication, which only suits transports that answer them locally such as recorded fixtures.
+func NewClient(options ...Option) *Client {
+	o := clientOptions{transport: http.DefaultTransport}
+	for _, option := range options {
+		option(&o)
+	}
+
+	var transport http.RoundTripper = &httplog.Transport{
+		Next:     o.transport,
+		Logger:   o.base.Logger,
+		Redactor: redact.New(append(append([]string{}, redact.DefaultProperties...), o.properties...), o.scopes...),
+	}
+	if o.source != nil {
+		transport = &oauth2.Transport{Source: oauth2.ReuseTokenSource(nil, o.source), Base: transport}
+	}
+	o.base.HTTPClient = &http.Client{Transport: transport}
+
+	return &Client{
+		BaseResource: o.base,
+		Users:        &UsersService{Service{base: o.base, path: "/users"}},
+		Groups:       &GroupsService{Service{base: o.base, path: "/groups"}},
+		Applications: &ApplicationsService{Service{base: o.base, path: "/applications"}},
+	}
+}
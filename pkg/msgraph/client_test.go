@@ -0,0 +1,86 @@
+package msgraph_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"westpac.co.nz/msgraph/pkg/graphtest"
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+type user struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+}
+
+func newTestClient(t *testing.T) *msgraph.Client {
+	server, err := graphtest.NewServer(graphtest.DefaultFixtures())
+	require.NoError(t, err)
+	t.Cleanup(server.Close)
+
+	return msgraph.NewClient(
+		msgraph.WithBaseURL(server.URL),
+		msgraph.WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: graphtest.AccessToken})),
+	)
+}
+
+func TestClientListUsers(t *testing.T) {
+	client := newTestClient(t)
+
+	var users []user
+	err := client.Users.List(context.Background(), nil, &users)
+
+	require.NoError(t, err)
+	assert.Len(t, users, 6, "every page is decoded")
+	assert.Equal(t, "Adele Vance", users[0].DisplayName)
+
+	var filtered []user
+	filter := new(msgraph.FilterCriteria)
+	err = client.Users.List(context.Background(), filter.StartWith("displayName", "Lee"), &filtered)
+
+	require.NoError(t, err)
+	assert.Equal(t, []user{{ID: users[4].ID, DisplayName: "Lee Gu"}}, filtered)
+}
+
+func TestClientGetByUserPrincipalName(t *testing.T) {
+	client := newTestClient(t)
+
+	var found user
+	err := client.Users.Get(context.Background(), "megan_fabrikam.com#EXT#@contoso.onmicrosoft.com", &found)
+
+	require.NoError(t, err, "the # of guest user principal names is escaped once")
+	assert.Equal(t, "Megan Bowen", found.DisplayName)
+}
+
+func TestClientGetNotFound(t *testing.T) {
+	client := newTestClient(t)
+
+	var found user
+	err := client.Users.Get(context.Background(), "00000000-0000-0000-0000-000000000000", &found)
+
+	require.Error(t, err)
+	graphError, ok := err.(*msgraph.GraphAPIError)
+	require.True(t, ok, "error is a %T", err)
+	assert.Equal(t, http.StatusNotFound, graphError.StatusCode)
+	assert.Equal(t, "Request_ResourceNotFound", graphError.Code)
+}
+
+func TestClientUserAgent(t *testing.T) {
+	var userAgents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.UserAgent())
+		w.Write([]byte(`{"value": []}`))
+	}))
+	defer server.Close()
+
+	var groups []user
+	require.NoError(t, msgraph.NewClient(msgraph.WithBaseURL(server.URL)).Groups.List(context.Background(), nil, &groups))
+	require.NoError(t, msgraph.NewClient(msgraph.WithBaseURL(server.URL), msgraph.WithUserAgent("provisioning/2.1")).Groups.List(context.Background(), nil, &groups))
+
+	assert.Equal(t, []string{msgraph.DefaultUserAgent, "provisioning/2.1"}, userAgents)
+}
@@ -0,0 +1,157 @@
+package msgraph
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// DefaultConcurrency the number of requests a FanOut runs at a time unless configured otherwise
+const DefaultConcurrency = 4
+
+// FanOutFunc the request made for one resource. It returns the resource to emit, or nil to emit nothing.
+type FanOutFunc func(ctx context.Context, resource Resource) (Resource, error)
+
+// FanOut runs a request per resource, such as looking up the owners of every group, with bounded
+// concurrency. The requests are sent by the caller's BaseResource, so they are retried and paced by its
+// retry policy and rate limiter, which every request of the fan-out shares.
+type FanOut struct {
+	// Concurrency the maximum number of requests running at a time, DefaultConcurrency when zero
+	Concurrency int
+	// Ordered emits the results in the order the resources were received instead of as they complete
+	Ordered bool
+	// CollectErrors continues after a failed request and returns every error as Errors. Otherwise the first
+	// error cancels the requests still running and is returned.
+	CollectErrors bool
+}
+
+// Errors the errors of the failed requests of a FanOut with CollectErrors
+type Errors []error
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for index, err := range e {
+		messages[index] = err.Error()
+	}
+	return fmt.Sprintf("%d requests failed: %s", len(e), strings.Join(messages, "; "))
+}
+
+// Resources a closed channel streaming resources, as input for FanOut.Run
+func Resources(resources []Resource) <-chan Resource {
+	stream := make(chan Resource, len(resources))
+	for _, resource := range resources {
+		stream <- resource
+	}
+	close(stream)
+	return stream
+}
+
+type fanOutJob struct {
+	index    int
+	resource Resource
+}
+
+type fanOutResult struct {
+	index    int
+	resource Resource
+	err      error
+}
+
+// Run calls request for every resource received from in until it is closed, and passes the results to emit
+// on the calling goroutine. Once Run fails or ctx is done it stops receiving from in, so producers should
+// give up sending when ctx is done.
+func (f FanOut) Run(ctx context.Context, in <-chan Resource, request FanOutFunc, emit func(Resource)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	concurrency := f.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	jobs := make(chan fanOutJob)
+	go func() {
+		defer close(jobs)
+		for index := 0; ; index++ {
+			select {
+			case resource, ok := <-in:
+				if !ok {
+					return
+				}
+				select {
+				case jobs <- fanOutJob{index, resource}:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	results := make(chan fanOutResult)
+	var workers sync.WaitGroup
+	for worker := 0; worker < concurrency; worker++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range jobs {
+				// once cancelled the remaining jobs are skipped, still passing their index on for ordering
+				var result fanOutResult
+				if ctx.Err() == nil {
+					result.resource, result.err = request(ctx, job.resource)
+				}
+				result.index = job.index
+				results <- result
+			}
+		}()
+	}
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	var firstErr error
+	var errs Errors
+	pending := map[int]fanOutResult{}
+	next := 0
+	for result := range results {
+		if result.err != nil {
+			if f.CollectErrors {
+				errs = append(errs, result.err)
+			} else if firstErr == nil {
+				firstErr = result.err
+				cancel()
+			}
+		}
+		if firstErr != nil {
+			continue
+		}
+		if !f.Ordered {
+			emitResult(result, emit)
+			continue
+		}
+		pending[result.index] = result
+		for ready, found := pending[next]; found; ready, found = pending[next] {
+			delete(pending, next)
+			emitResult(ready, emit)
+			next++
+		}
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	// the parent context ended the fan-out early
+	return ctx.Err()
+}
+
+func emitResult(result fanOutResult, emit func(Resource)) {
+	if result.err == nil && result.resource != nil {
+		emit(result.resource)
+	}
+}
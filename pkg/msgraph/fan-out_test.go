@@ -0,0 +1,95 @@
+package msgraph
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testResources(names ...string) []Resource {
+	resources := make([]Resource, len(names))
+	for index, name := range names {
+		resources[index] = testResource{DisplayName: name}
+	}
+	return resources
+}
+
+// slowly a request taking the delay of the resource name, failing for "fail"
+func slowly(delays map[string]time.Duration) FanOutFunc {
+	return func(ctx context.Context, resource Resource) (Resource, error) {
+		name := resource.(testResource).DisplayName
+		select {
+		case <-time.After(delays[name]):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if name == "fail" {
+			return nil, errors.New("failed")
+		}
+		return testResource{DisplayName: name + "!"}, nil
+	}
+}
+
+func emitted(names *[]string) func(Resource) {
+	return func(resource Resource) {
+		*names = append(*names, resource.ToString())
+	}
+}
+
+func TestFanOutOrdered(t *testing.T) {
+	var names []string
+	delays := map[string]time.Duration{"a": 30 * time.Millisecond, "b": 20 * time.Millisecond, "c": 10 * time.Millisecond}
+
+	err := FanOut{Concurrency: 3, Ordered: true}.Run(context.Background(), Resources(testResources("a", "b", "c")), slowly(delays), emitted(&names))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a!", "b!", "c!"}, names)
+}
+
+func TestFanOutConcurrency(t *testing.T) {
+	var running, maximum int32
+	request := func(ctx context.Context, resource Resource) (Resource, error) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			seen := atomic.LoadInt32(&maximum)
+			if current <= seen || atomic.CompareAndSwapInt32(&maximum, seen, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil, nil
+	}
+
+	var names []string
+	err := FanOut{Concurrency: 2}.Run(context.Background(), Resources(testResources("a", "b", "c", "d", "e", "f")), request, emitted(&names))
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), maximum)
+	assert.Empty(t, names, "nil results are not emitted")
+}
+
+func TestFanOutFirstError(t *testing.T) {
+	var names []string
+	delays := map[string]time.Duration{"fail": 0, "slow": time.Minute}
+
+	start := time.Now()
+	err := FanOut{Concurrency: 2}.Run(context.Background(), Resources(testResources("fail", "slow", "a")), slowly(delays), emitted(&names))
+
+	assert.EqualError(t, err, "failed")
+	assert.True(t, time.Since(start) < time.Second, "the slow request is cancelled")
+	assert.Empty(t, names)
+}
+
+func TestFanOutCollectErrors(t *testing.T) {
+	var names []string
+
+	err := FanOut{Ordered: true, CollectErrors: true}.Run(context.Background(), Resources(testResources("a", "fail", "b", "fail")), slowly(nil), emitted(&names))
+
+	assert.EqualError(t, err, "2 requests failed: failed; failed")
+	assert.Equal(t, []string{"a!", "b!"}, names)
+}
@@ -0,0 +1,183 @@
+package msgraph
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Field a property of a resource
+type Field struct {
+	// Name the JSON property name
+	Name string
+	// GoName the struct field name, empty for additional properties
+	GoName string
+	Value  interface{}
+}
+
+var additionalPropertiesType = reflect.TypeOf(AdditionalProperties{})
+
+// ResourceFields the declared properties of resource in declaration order, followed by its additional properties sorted by name
+func ResourceFields(resource Resource) []Field {
+	var fields []Field
+	value := reflect.Indirect(reflect.ValueOf(resource))
+	if value.Kind() != reflect.Struct {
+		return fields
+	}
+
+	var additional AdditionalProperties
+	fields = structFields(value, fields, &additional)
+
+	var names []string
+	for name := range additional {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fields = append(fields, Field{Name: name, Value: additional[name]})
+	}
+	return fields
+}
+
+// structFields appends the fields of a struct value to fields, flattening embedded structs like encoding/json
+func structFields(value reflect.Value, fields []Field, additional *AdditionalProperties) []Field {
+	for i := 0; i < value.NumField(); i++ {
+		structField := value.Type().Field(i)
+		name := strings.Split(structField.Tag.Get("json"), ",")[0]
+		if structField.Anonymous && structField.Type.Kind() == reflect.Struct && name == "" {
+			fields = structFields(value.Field(i), fields, additional)
+			continue
+		}
+		if structField.PkgPath != "" {
+			continue
+		}
+		if structField.Type == additionalPropertiesType {
+			*additional = value.Field(i).Interface().(AdditionalProperties)
+			continue
+		}
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = structField.Name
+		}
+		fields = append(fields, Field{Name: name, GoName: structField.Name, Value: value.Field(i).Interface()})
+	}
+	return fields
+}
+
+// PIIPropertyNames the JSON property names of the fields of resource tagged `pii:"true"`, those holding personal data
+func PIIPropertyNames(resource Resource) []string {
+	var names []string
+	value := reflect.Indirect(reflect.ValueOf(resource))
+	if value.Kind() != reflect.Struct {
+		return names
+	}
+	for index := 0; index < value.NumField(); index++ {
+		structField := value.Type().Field(index)
+		if structField.Tag.Get("pii") != "true" {
+			continue
+		}
+		name := strings.Split(structField.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = structField.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// FindField the property of resource called name, matched case insensitively against the JSON property and
+// struct field names. A dotted name selects a nested value, for example signInActivity.lastSignInDateTime.
+func FindField(resource Resource, name string) (Field, bool) {
+	path := strings.Split(name, ".")
+	for _, field := range ResourceFields(resource) {
+		if !strings.EqualFold(field.Name, path[0]) && !strings.EqualFold(field.GoName, path[0]) {
+			continue
+		}
+		if len(path) == 1 {
+			return field, true
+		}
+		value, found := nestedValue(field.Value, path[1:])
+		return Field{Name: field.Name + "." + strings.Join(path[1:], "."), Value: value}, found
+	}
+	return Field{Name: name}, false
+}
+
+// SelectFields the properties of resource called names, in the order of names. Properties the resource
+// does not have are returned with a nil value so every resource yields the same columns.
+func SelectFields(resource Resource, names []string) []Field {
+	var fields = make([]Field, len(names))
+	for index, name := range names {
+		fields[index], _ = FindField(resource, name)
+	}
+	return fields
+}
+
+// nestedValue walks path through the JSON representation of value
+func nestedValue(value interface{}, path []string) (interface{}, bool) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, false
+	}
+
+	for _, name := range path {
+		object, ok := generic.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		found := false
+		for key, child := range object {
+			if strings.EqualFold(key, name) {
+				generic, found = child, true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return generic, true
+}
+
+// MarshalResource marshals resource (a resource struct converted to a local alias type without its own
+// MarshalJSON) with its additional properties written next to the declared ones
+func MarshalResource(resource interface{}, additional AdditionalProperties) ([]byte, error) {
+	data, err := json.Marshal(resource)
+	if err != nil || len(additional) == 0 {
+		return data, err
+	}
+	extra, err := json.Marshal(additional)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == "{}" {
+		return extra, nil
+	}
+	return append(append(data[:len(data)-1], ','), extra[1:]...), nil
+}
+
+// SelectQuery the $select query option for the properties called names of the resources of r. Names are
+// mapped to the JSON property names the API expects, nested names select their top level property.
+func SelectQuery(r ResourceAPI, names []string) string {
+	prototype := r.ConvertToResource([]byte("{}"))
+
+	selected := []string{"id"}
+	seen := map[string]bool{"id": true}
+	for _, name := range names {
+		top := strings.Split(name, ".")[0]
+		if field, found := FindField(prototype, top); found {
+			top = field.Name
+		}
+		if !seen[strings.ToLower(top)] {
+			seen[strings.ToLower(top)] = true
+			selected = append(selected, top)
+		}
+	}
+	return strings.Join(selected, ",")
+}
@@ -0,0 +1,65 @@
+package msgraph
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (r testResource) ToString() string {
+	return r.DisplayName
+}
+
+func TestResourceFields(t *testing.T) {
+	resource := testResource{ID: "1", DisplayName: "Alice", AdditionalProperties: AdditionalProperties{
+		"employeeId":     "E1",
+		"accountEnabled": true,
+	}}
+
+	assert.Equal(t, []Field{
+		{Name: "id", GoName: "ID", Value: "1"},
+		{Name: "displayName", GoName: "DisplayName", Value: "Alice"},
+		{Name: "accountEnabled", Value: true},
+		{Name: "employeeId", Value: "E1"},
+	}, ResourceFields(resource))
+}
+
+func TestSelectFields(t *testing.T) {
+	resource := testResource{ID: "1", DisplayName: "Alice", AdditionalProperties: AdditionalProperties{
+		"onPremisesExtensionAttributes": map[string]interface{}{"extensionAttribute1": "cost centre"},
+	}}
+
+	fields := SelectFields(resource, []string{"DisplayName", "onpremisesextensionattributes.extensionAttribute1", "department"})
+
+	assert.Equal(t, []Field{
+		{Name: "displayName", GoName: "DisplayName", Value: "Alice"},
+		{Name: "onPremisesExtensionAttributes.extensionAttribute1", Value: "cost centre"},
+		{Name: "department"},
+	}, fields)
+}
+
+func TestMarshalResourceRoundTrip(t *testing.T) {
+	data := `{"id":"1","displayName":"Alice","accountEnabled":true,"department":"IT"}`
+
+	var resource testResource
+	properties, err := UnmarshalResource([]byte(data), &resource)
+	assert.NoError(t, err)
+
+	marshalled, err := MarshalResource(resource, properties)
+	assert.NoError(t, err)
+	assert.JSONEq(t, data, string(marshalled))
+
+	marshalled, err = MarshalResource(resource, nil)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":"1","displayName":"Alice"}`, string(marshalled))
+}
+
+func TestMarshalResourceEmpty(t *testing.T) {
+	marshalled, err := MarshalResource(struct{}{}, AdditionalProperties{"department": "IT"})
+	assert.NoError(t, err)
+
+	var decoded map[string]interface{}
+	assert.NoError(t, json.Unmarshal(marshalled, &decoded))
+	assert.Equal(t, map[string]interface{}{"department": "IT"}, decoded)
+}
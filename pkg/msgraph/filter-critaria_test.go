@@ -34,12 +34,20 @@ func (suite *FilterCriteriaTestSuite) TestMyFunc() {
 	criteria := filter.LogicOr(filter.StartWith("field1", "startwith1"), filter.StartWith("field2", "startwith2"))
 	assert.Equal(
 		suite.T(),
-		"startwith(field1,'startwith1') OR startwith(field2,'startwith2')",
+		"startswith(field1,'startwith1') or startswith(field2,'startwith2')",
 		(*criteria).String(),
 	)
 }
 func (suite *FilterCriteriaTestSuite) TestMyFunc2() {
 	fmt.Println("TestMyFunc2")
+
+	filter := new(FilterCriteria)
+	criteria := filter.LogicAnd(filter.Equals("appId", "1234"), filter.LogicNot(filter.StartWith("displayName", "test")))
+	assert.Equal(
+		suite.T(),
+		"appId eq '1234' and not startswith(displayName,'test')",
+		(*criteria).String(),
+	)
 }
 
 func TestMyTestSuite(t *testing.T) {
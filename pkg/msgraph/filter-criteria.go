@@ -1,6 +1,9 @@
 package msgraph
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type FilterCriteria struct {
 }
@@ -26,6 +29,11 @@ type StartWithCriteria struct {
 	StartWith string
 }
 
+type EqualsCriteria struct {
+	Field string
+	Value string
+}
+
 func (c *FilterCriteria) LogicOr(criteria1 *Criteria, criteria2 *Criteria) *Criteria {
 	op := BinaryLogicOperator{OR, criteria1, criteria2}
 	criteria := Criteria(op)
@@ -49,20 +57,35 @@ func (c *FilterCriteria) StartWith(field string, startWith string) *Criteria {
 	return &criteria
 }
 
+func (c *FilterCriteria) Equals(field string, value string) *Criteria {
+	equals := EqualsCriteria{field, value}
+	criteria := Criteria(equals)
+	return &criteria
+}
+
 func (c BinaryLogicOperator) String() string {
 	format := "%s %s %s"
-	op := "AND"
+	op := "and"
 	switch c.operator {
 	case AND:
-		op = "AND"
+		op = "and"
 	case OR:
-		op = "OR"
+		op = "or"
 	case NOT:
-		return fmt.Sprintf("NOT %s", (*c.criteria1).String())
+		return fmt.Sprintf("not %s", (*c.criteria1).String())
 	}
 	return fmt.Sprintf(format, (*c.criteria1).String(), op, (*c.criteria2).String())
 }
 
 func (c StartWithCriteria) String() string {
-	return fmt.Sprintf("startswith(%s,'%s')", c.Field, c.StartWith)
+	return fmt.Sprintf("startswith(%s,'%s')", c.Field, escapeLiteral(c.StartWith))
+}
+
+func (c EqualsCriteria) String() string {
+	return fmt.Sprintf("%s eq '%s'", c.Field, escapeLiteral(c.Value))
+}
+
+// escapeLiteral doubles single quotes as required for OData string literals
+func escapeLiteral(value string) string {
+	return strings.ReplaceAll(value, "'", "''")
 }
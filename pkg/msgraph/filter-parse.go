@@ -0,0 +1,214 @@
+package msgraph
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ParseFilter parses the subset of OData $filter expressions FilterCriteria builds: startswith(field,'value'),
+// field eq 'value', and, or, not and parentheses
+func ParseFilter(filter string) (*Criteria, error) {
+	tokens, err := filterTokens(filter)
+	if err != nil {
+		return nil, err
+	}
+	parser := filterParser{tokens: tokens}
+	criteria, err := parser.or()
+	if err != nil {
+		return nil, err
+	}
+	if token := parser.peek(); token != "" {
+		return nil, fmt.Errorf("invalid filter %q: unexpected %s", filter, token)
+	}
+	return criteria, nil
+}
+
+// Matches reports whether object, a resource decoded from JSON, satisfies criteria. Like the Graph API
+// string comparisons ignore case. Nested properties are addressed with '/', e.g. onPremisesExtensionAttributes/extensionAttribute1.
+func Matches(criteria *Criteria, object map[string]interface{}) bool {
+	switch c := (*criteria).(type) {
+	case BinaryLogicOperator:
+		switch c.operator {
+		case AND:
+			return Matches(c.criteria1, object) && Matches(c.criteria2, object)
+		case OR:
+			return Matches(c.criteria1, object) || Matches(c.criteria2, object)
+		case NOT:
+			return !Matches(c.criteria1, object)
+		}
+	case StartWithCriteria:
+		value, ok := propertyValue(object, c.Field).(string)
+		return ok && strings.HasPrefix(strings.ToLower(value), strings.ToLower(c.StartWith))
+	case EqualsCriteria:
+		value := propertyValue(object, c.Field)
+		if value == nil {
+			return false
+		}
+		return strings.EqualFold(fmt.Sprint(value), c.Value)
+	}
+	return false
+}
+
+// propertyValue the value of the property at path in object, nil when it has none
+func propertyValue(object map[string]interface{}, path string) interface{} {
+	var value interface{} = object
+	for _, name := range strings.Split(path, "/") {
+		properties, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = nil
+		for key, child := range properties {
+			if strings.EqualFold(key, name) {
+				value = child
+				break
+			}
+		}
+	}
+	return value
+}
+
+type filterParser struct {
+	tokens []string
+	filter FilterCriteria
+}
+
+func (p *filterParser) peek() string {
+	if len(p.tokens) == 0 {
+		return ""
+	}
+	return p.tokens[0]
+}
+
+func (p *filterParser) next() string {
+	token := p.peek()
+	if token != "" {
+		p.tokens = p.tokens[1:]
+	}
+	return token
+}
+
+func (p *filterParser) expect(expected string) error {
+	if token := p.next(); !strings.EqualFold(token, expected) {
+		return fmt.Errorf("invalid filter: expected %s, got %q", expected, token)
+	}
+	return nil
+}
+
+func (p *filterParser) or() (*Criteria, error) {
+	criteria, err := p.and()
+	for err == nil && strings.EqualFold(p.peek(), "or") {
+		p.next()
+		var right *Criteria
+		if right, err = p.and(); err == nil {
+			criteria = p.filter.LogicOr(criteria, right)
+		}
+	}
+	return criteria, err
+}
+
+func (p *filterParser) and() (*Criteria, error) {
+	criteria, err := p.unary()
+	for err == nil && strings.EqualFold(p.peek(), "and") {
+		p.next()
+		var right *Criteria
+		if right, err = p.unary(); err == nil {
+			criteria = p.filter.LogicAnd(criteria, right)
+		}
+	}
+	return criteria, err
+}
+
+func (p *filterParser) unary() (*Criteria, error) {
+	token := p.next()
+	switch {
+	case strings.EqualFold(token, "not"):
+		criteria, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return p.filter.LogicNot(criteria), nil
+	case token == "(":
+		criteria, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return criteria, p.expect(")")
+	case strings.EqualFold(token, "startswith"):
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		field := p.next()
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		value, err := p.literal()
+		if err != nil {
+			return nil, err
+		}
+		return p.filter.StartWith(field, value), p.expect(")")
+	case isFilterIdentifier(token):
+		if err := p.expect("eq"); err != nil {
+			return nil, err
+		}
+		value, err := p.literal()
+		if err != nil {
+			return nil, err
+		}
+		return p.filter.Equals(token, value), nil
+	}
+	return nil, fmt.Errorf("invalid filter: unexpected %q", token)
+}
+
+func (p *filterParser) literal() (string, error) {
+	token := p.next()
+	if len(token) < 2 || !strings.HasPrefix(token, "'") {
+		return "", fmt.Errorf("invalid filter: expected a string literal, got %q", token)
+	}
+	return strings.ReplaceAll(token[1:len(token)-1], "''", "'"), nil
+}
+
+func isFilterIdentifier(token string) bool {
+	return token != "" && (unicode.IsLetter(rune(token[0])) || token[0] == '_')
+}
+
+// filterTokens splits filter into identifiers, string literals (with their quotes) and punctuation
+func filterTokens(filter string) ([]string, error) {
+	var tokens []string
+	runes := []rune(filter)
+	for i := 0; i < len(runes); {
+		switch r := runes[i]; {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == ',':
+			tokens = append(tokens, string(r))
+			i++
+		case r == '\'':
+			end := i + 1
+			for {
+				if end >= len(runes) {
+					return nil, fmt.Errorf("invalid filter %q: unterminated string", filter)
+				}
+				if runes[end] == '\'' {
+					if end+1 < len(runes) && runes[end+1] == '\'' {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			tokens = append(tokens, string(runes[i:end+1]))
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune("(),'", runes[end]) {
+				end++
+			}
+			tokens = append(tokens, string(runes[i:end]))
+			i = end
+		}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,48 @@
+package msgraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilter(t *testing.T) {
+	for _, filter := range []string{
+		"startswith(displayName,'Al')",
+		"appId eq '1234' and not startswith(displayName,'test')",
+		"startswith(field1,'a') or startswith(field2,'b')",
+		"mail eq 'o''brien@contoso.com'",
+	} {
+		criteria, err := ParseFilter(filter)
+		assert.NoError(t, err, filter)
+		assert.Equal(t, filter, (*criteria).String())
+	}
+
+	for _, filter := range []string{"", "startswith(displayName)", "mail eq", "mail eq 'unterminated", "mail gt 'a'", "(mail eq 'a'"} {
+		_, err := ParseFilter(filter)
+		assert.Error(t, err, filter)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	object := map[string]interface{}{
+		"displayName":                   "Alice Smith",
+		"accountEnabled":                true,
+		"onPremisesExtensionAttributes": map[string]interface{}{"extensionAttribute1": "IT"},
+	}
+
+	for filter, expected := range map[string]bool{
+		"startswith(displayName,'alice')":                                    true,
+		"startswith(displayName,'Bob')":                                      false,
+		"displayName eq 'ALICE SMITH'":                                       true,
+		"accountEnabled eq 'true'":                                           true,
+		"onPremisesExtensionAttributes/extensionAttribute1 eq 'IT'":          true,
+		"department eq 'IT'":                                                 false,
+		"not startswith(displayName,'Bob') and displayName eq 'alice smith'": true,
+		"(startswith(displayName,'Bob') or startswith(displayName,'Al')) and not accountEnabled eq 'false'": true,
+	} {
+		criteria, err := ParseFilter(filter)
+		assert.NoError(t, err, filter)
+		assert.Equal(t, expected, Matches(criteria, object), filter)
+	}
+}
@@ -0,0 +1,58 @@
+package msgraph
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// AdditionalProperties properties returned by the API that a resource struct does not declare,
+// for example beta only properties or directory extensions
+type AdditionalProperties map[string]interface{}
+
+// UnmarshalResource unmarshals data into resource and returns the properties resource does not declare.
+// resource must be a pointer to a struct type without its own UnmarshalJSON, resources call it from
+// their UnmarshalJSON through a local alias type. OData annotations are not kept.
+func UnmarshalResource(data []byte, resource interface{}) (AdditionalProperties, error) {
+	if err := json.Unmarshal(data, resource); err != nil {
+		return nil, err
+	}
+
+	var properties AdditionalProperties
+	if err := json.Unmarshal(data, &properties); err != nil {
+		return nil, err
+	}
+	for _, name := range JSONPropertyNames(reflect.TypeOf(resource).Elem()) {
+		delete(properties, name)
+	}
+	for name := range properties {
+		if strings.HasPrefix(name, "@odata.") {
+			delete(properties, name)
+		}
+	}
+
+	if len(properties) == 0 {
+		return nil, nil
+	}
+	return properties, nil
+}
+
+// JSONPropertyNames the JSON property names of the fields of a struct type
+func JSONPropertyNames(structType reflect.Type) []string {
+	var names []string
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,41 @@
+package msgraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testResource struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	Ignored     string `json:"-"`
+
+	AdditionalProperties AdditionalProperties `json:"-"`
+}
+
+func TestUnmarshalResource(t *testing.T) {
+	var resource testResource
+	properties, err := UnmarshalResource([]byte(`{
+		"@odata.context": "https://graph.microsoft.com/beta/$metadata#users/$entity",
+		"id": "1",
+		"displayName": "Alice",
+		"accountEnabled": true,
+		"signInActivity": {"lastSignInDateTime": "2020-11-05T08:17:45Z"}
+	}`), &resource)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice", resource.DisplayName)
+	assert.Equal(t, AdditionalProperties{
+		"accountEnabled": true,
+		"signInActivity": map[string]interface{}{"lastSignInDateTime": "2020-11-05T08:17:45Z"},
+	}, properties)
+}
+
+func TestUnmarshalResourceNoAdditionalProperties(t *testing.T) {
+	var resource testResource
+	properties, err := UnmarshalResource([]byte(`{"id": "1"}`), &resource)
+
+	assert.NoError(t, err)
+	assert.Nil(t, properties)
+}
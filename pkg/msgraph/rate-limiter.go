@@ -0,0 +1,108 @@
+package msgraph
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ResponseObserver is implemented by rate limiters that adapt to the responses of the API.
+// BaseResource passes it the response of every attempt.
+type ResponseObserver interface {
+	Observe(resp *http.Response)
+}
+
+const (
+	// RateRecovery the time a TokenBucket takes to recover its configured rate after halving it,
+	// provided it is not throttled again
+	RateRecovery = 10 * time.Second
+	// minRateDivisor limits how far throttling lowers the rate: to the configured rate divided by it
+	minRateDivisor = 16
+)
+
+// TokenBucket a RateLimiter allowing rate requests per second on average and bursts of up to burst requests.
+// Every throttled (429) response halves the rate, which then recovers linearly over RateRecovery.
+// A TokenBucket is safe for concurrent use and meant to be shared by every goroutine using one client.
+type TokenBucket struct {
+	mutex     sync.Mutex
+	limit     float64
+	rate      float64
+	burst     float64
+	tokens    float64
+	last      time.Time
+	throttled time.Time
+	now       func() time.Time
+}
+
+// NewTokenBucket a full bucket allowing rate requests per second, which must be positive, and bursts of
+// burst requests
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	b := &TokenBucket{limit: rate, rate: rate, burst: float64(burst), tokens: float64(burst), now: time.Now}
+	b.last = b.now()
+	return b
+}
+
+// Rate the current rate in requests per second, lower than the configured one after throttling
+func (b *TokenBucket) Rate() float64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.refill()
+	return b.rate
+}
+
+// Wait takes a token from the bucket, waiting for it when the bucket is empty or until ctx is done
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	b.mutex.Lock()
+	b.refill()
+	b.tokens--
+	delay := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.mutex.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	if err := sleep(ctx, delay); err != nil {
+		// give the reserved token back for the requests still waiting
+		b.mutex.Lock()
+		b.tokens++
+		b.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Observe halves the rate when resp was throttled, at most once per second so that the responses to a
+// burst of concurrent requests lower it only once
+func (b *TokenBucket) Observe(resp *http.Response) {
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.refill()
+
+	now := b.now()
+	if now.Sub(b.throttled) < time.Second {
+		return
+	}
+	b.throttled = now
+	b.rate = math.Max(b.rate/2, b.limit/minRateDivisor)
+	b.tokens = math.Min(b.tokens, 0)
+}
+
+// refill adds the tokens accumulated since the last call and recovers the rate, with the mutex held
+func (b *TokenBucket) refill() {
+	now := b.now()
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+	b.rate = math.Min(b.limit, b.rate+b.limit*elapsed/RateRecovery.Seconds())
+}
@@ -0,0 +1,67 @@
+package msgraph
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucketPaces(t *testing.T) {
+	bucket := NewTokenBucket(100, 5)
+
+	start := time.Now()
+	var wait sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for j := 0; j < 5; j++ {
+				assert.NoError(t, bucket.Wait(context.Background()))
+			}
+		}()
+	}
+	wait.Wait()
+
+	// 5 requests in the burst, the other 15 at 100 per second
+	assert.True(t, time.Since(start) >= 140*time.Millisecond, "took %s", time.Since(start))
+}
+
+func TestTokenBucketCancelled(t *testing.T) {
+	bucket := NewTokenBucket(0.1, 1)
+	require.NoError(t, bucket.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, bucket.Wait(ctx))
+}
+
+func TestTokenBucketAdapts(t *testing.T) {
+	now := time.Now()
+	bucket := NewTokenBucket(8, 1)
+	bucket.now = func() time.Time { return now }
+	throttled := &http.Response{StatusCode: http.StatusTooManyRequests}
+
+	bucket.Observe(throttled)
+	bucket.Observe(throttled)
+	assert.Equal(t, 4.0, bucket.Rate(), "throttled responses within a second halve the rate once")
+
+	now = now.Add(time.Second)
+	bucket.Observe(&http.Response{StatusCode: http.StatusOK})
+	bucket.Observe(throttled)
+	assert.InDelta(t, 2.4, bucket.Rate(), 0.001, "recovered by 0.8 and halved")
+
+	for i := 0; i < 10; i++ {
+		bucket.throttled = time.Time{}
+		bucket.Observe(throttled)
+	}
+	assert.Equal(t, 0.5, bucket.Rate(), "not lowered below a sixteenth")
+
+	now = now.Add(RateRecovery)
+	assert.Equal(t, 8.0, bucket.Rate(), "recovered")
+}
@@ -0,0 +1,110 @@
+package msgraph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RawMethods the HTTP methods RawRequest sends
+var RawMethods = []string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodPut, http.MethodDelete}
+
+// RawRequest sends a request to an endpoint without a resource type and returns the response body as is.
+// path is a URL of the Graph endpoint, a path starting with the API version such as /v1.0/organization,
+// or a path relative to Version. It may include a query. body, when not nil, is sent as JSON. With all,
+// the pages following a GET response are retrieved as well and their values merged into its value.
+func (b BaseResource) RawRequest(ctx context.Context, method, path string, body interface{}, all bool) ([]byte, error) {
+	method = strings.ToUpper(method)
+	if !contains(RawMethods, method) {
+		return nil, fmt.Errorf("unsupported method %s, expected one of %s", method, RawMethods)
+	}
+	u, err := b.rawURL(path)
+	if err != nil {
+		return nil, err
+	}
+	request, err := b.newRequestURL(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if !all || method != http.MethodGet {
+		return b.do(ctx, request)
+	}
+
+	var first map[string]json.RawMessage
+	var values []json.RawMessage
+	err = b.pagesFrom(ctx, request, func(body []byte) error {
+		var page map[string]json.RawMessage
+		if err := json.Unmarshal(body, &page); err != nil {
+			return fmt.Errorf("JSON unmarshalling of response body failed: %v", err)
+		}
+		var value []json.RawMessage
+		if raw, found := page["value"]; found {
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return fmt.Errorf("response value is not a list: %v", err)
+			}
+		}
+		if first == nil {
+			first = page
+		}
+		values = append(values, value...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, collection := first["value"]; !collection {
+		return json.Marshal(first)
+	}
+
+	delete(first, "@odata.nextLink")
+	if values == nil {
+		values = []json.RawMessage{}
+	}
+	if first["value"], err = json.Marshal(values); err != nil {
+		return nil, err
+	}
+	return json.Marshal(first)
+}
+
+// rawURL resolves path against the Graph endpoint. URLs of other hosts or schemes are refused, they would be
+// sent the access token, in the clear for http.
+func (b BaseResource) rawURL(path string) (string, error) {
+	base, err := url.Parse(b.baseURL())
+	if err != nil {
+		return "", fmt.Errorf("invalid graph API URL: %v", err)
+	}
+	target, err := url.Parse(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid path %s: %v", path, err)
+	}
+
+	if target.IsAbs() {
+		if !strings.EqualFold(target.Host, base.Host) {
+			return "", fmt.Errorf("refusing to send a request for %s to a host other than %s", path, base.Host)
+		}
+		if !strings.EqualFold(target.Scheme, base.Scheme) {
+			return "", fmt.Errorf("refusing to send a request for %s with a scheme other than %s", path, base.Scheme)
+		}
+		return target.String(), nil
+	}
+
+	if !strings.HasPrefix(target.Path, "/") {
+		target.Path = "/" + target.Path
+	}
+	if !contains(Versions, strings.SplitN(strings.TrimPrefix(target.Path, "/"), "/", 2)[0]) {
+		target.Path = b.versionedPath(target.Path)
+	}
+	return below(base, target.Path, target.RawQuery), nil
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package msgraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawURL(t *testing.T) {
+	b := BaseResource{BaseURL: "https://graph.microsoft.com/", Version: VersionBeta}
+
+	for path, expected := range map[string]string{
+		"/v1.0/organization": "https://graph.microsoft.com/v1.0/organization",
+		"/users?$top=5":      "https://graph.microsoft.com/beta/users?$top=5",
+		"me/memberOf":        "https://graph.microsoft.com/beta/me/memberOf",
+		"https://graph.microsoft.com/v1.0/users?$skiptoken=X": "https://graph.microsoft.com/v1.0/users?$skiptoken=X",
+	} {
+		u, err := b.rawURL(path)
+		assert.NoError(t, err, path)
+		assert.Equal(t, expected, u, path)
+	}
+
+	prefixed := BaseResource{BaseURL: "https://proxy.example/graph"}
+	u, err := prefixed.rawURL("/users?$top=5")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://proxy.example/graph/v1.0/users?$top=5", u, "the path of the graph URL is kept")
+
+	_, err = b.rawURL("https://attacker.example.com/v1.0/users")
+	assert.EqualError(t, err, "refusing to send a request for https://attacker.example.com/v1.0/users to a host other than graph.microsoft.com")
+
+	_, err = b.rawURL("http://graph.microsoft.com/v1.0/users")
+	assert.EqualError(t, err, "refusing to send a request for http://graph.microsoft.com/v1.0/users with a scheme other than https")
+}
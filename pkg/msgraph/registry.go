@@ -0,0 +1,143 @@
+package msgraph
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Verb a command every registered resource supporting it gets
+type Verb string
+
+const (
+	// VerbList lists the resources, optionally filtered
+	VerbList Verb = "list"
+	// VerbGet gets a single resource by id
+	VerbGet Verb = "get"
+	// VerbDelete deletes a resource by id
+	VerbDelete Verb = "delete"
+)
+
+// Registration declares a resource to the command line: the command named after it, the verbs it supports
+// and how its resources are shown
+type Registration struct {
+	// Name the entity set, e.g. servicePrincipals, used as command name
+	Name string
+	// Aliases the short command names
+	Aliases []string
+	// Plural the resources in usage texts, e.g. service principals. Name when empty.
+	Plural string
+	// ODataType the @odata.type of the resources, e.g. #microsoft.graph.user, telling them apart in mixed
+	// collections such as the owners of a group
+	ODataType string
+	// Description the command description, "Actions for the <Name> resource" when empty
+	Description string
+	// ListArgument what the optional argument of list filters on, e.g. name start
+	ListArgument string
+	// API the requests and responses of the resource
+	API ResourceAPI
+	// DefaultColumns the properties shown by the table output unless --fields is given
+	DefaultColumns []string
+	// Filterable the properties list filters on with --where property=value
+	Filterable []string
+	// Verbs the commands of the resource
+	Verbs []Verb
+
+	// resourceType the type API converts responses to, to find the registration of a resource
+	resourceType reflect.Type
+}
+
+var registry = struct {
+	sync.Mutex
+	registrations map[string]Registration
+}{registrations: map[string]Registration{}}
+
+// Register adds a resource to the registry, typically from an init function of the file declaring it.
+// Registering a name twice panics.
+func Register(registration Registration) {
+	registry.Lock()
+	defer registry.Unlock()
+	if _, found := registry.registrations[registration.Name]; found {
+		panic(fmt.Sprintf("resource %s registered twice", registration.Name))
+	}
+	registration.resourceType = reflect.TypeOf(registration.API.ConvertToResource([]byte("{}")))
+	registry.registrations[registration.Name] = registration
+}
+
+// Registrations the registered resources, ordered by name
+func Registrations() []Registration {
+	registry.Lock()
+	defer registry.Unlock()
+	var registrations []Registration
+	for _, registration := range registry.registrations {
+		registrations = append(registrations, registration)
+	}
+	sort.Slice(registrations, func(i, j int) bool { return registrations[i].Name < registrations[j].Name })
+	return registrations
+}
+
+// Registered the registration of the resource with name
+func Registered(name string) (Registration, bool) {
+	registry.Lock()
+	defer registry.Unlock()
+	registration, found := registry.registrations[name]
+	return registration, found
+}
+
+// DefaultColumns the default table columns of a resource: those it declares itself, otherwise those of its
+// registration
+func DefaultColumns(resource Resource) []string {
+	if defaults, ok := resource.(DefaultColumnsResource); ok {
+		return defaults.DefaultColumns()
+	}
+	for _, registration := range Registrations() {
+		if registration.resourceType == reflect.TypeOf(resource) {
+			return registration.DefaultColumns
+		}
+	}
+	return nil
+}
+
+// Supports reports whether the resource has the verb
+func (r Registration) Supports(verb Verb) bool {
+	for _, supported := range r.Verbs {
+		if supported == verb {
+			return true
+		}
+	}
+	return false
+}
+
+// PluralName the resources in usage texts
+func (r Registration) PluralName() string {
+	if r.Plural != "" {
+		return r.Plural
+	}
+	return r.Name
+}
+
+// Where the criteria of property=value conditions, all of which must hold. Only Filterable properties
+// are accepted.
+func (r Registration) Where(conditions []string) (*Criteria, error) {
+	filter := new(FilterCriteria)
+	var criteria *Criteria
+	for _, condition := range conditions {
+		parts := strings.SplitN(condition, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid condition '%s', expected property=value", condition)
+		}
+		property := strings.TrimSpace(parts[0])
+		if !contains(r.Filterable, property) {
+			return nil, fmt.Errorf("%s cannot be filtered on %s, expected one of %s", r.Name, property, r.Filterable)
+		}
+		equals := filter.Equals(property, parts[1])
+		if criteria == nil {
+			criteria = equals
+		} else {
+			criteria = filter.LogicAnd(criteria, equals)
+		}
+	}
+	return criteria, nil
+}
@@ -0,0 +1,72 @@
+package msgraph
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+type testResourceAPI struct{}
+
+func (r testResourceAPI) ConvertToResourceSlice(body []byte) []Resource {
+	return nil
+}
+
+func (r testResourceAPI) ConvertToResource(body []byte) Resource {
+	var resource testResource
+	_ = json.Unmarshal(body, &resource)
+	return resource
+}
+
+func (r testResourceAPI) CreateQueryParams(context cli.Context, args cli.Args) url.Values {
+	return nil
+}
+
+func (r testResourceAPI) CreateRequestPath(context cli.Context, args cli.Args) string {
+	return "/tests"
+}
+
+func init() {
+	Register(Registration{
+		Name:           "tests",
+		API:            testResourceAPI{},
+		DefaultColumns: []string{"id", "displayName"},
+		Filterable:     []string{"mail", "department"},
+		Verbs:          []Verb{VerbList, VerbGet},
+	})
+}
+
+func TestRegistered(t *testing.T) {
+	registration, found := Registered("tests")
+
+	assert.True(t, found)
+	assert.True(t, registration.Supports(VerbGet))
+	assert.False(t, registration.Supports(VerbDelete))
+	assert.Equal(t, "tests", registration.PluralName())
+	assert.Panics(t, func() { Register(Registration{Name: "tests", API: testResourceAPI{}}) })
+}
+
+func TestDefaultColumns(t *testing.T) {
+	assert.Equal(t, []string{"id", "displayName"}, DefaultColumns(testResource{DisplayName: "Alice"}))
+}
+
+func TestWhere(t *testing.T) {
+	registration, _ := Registered("tests")
+
+	criteria, err := registration.Where([]string{"mail=alice@contoso.com", "department=R&D"})
+	assert.NoError(t, err)
+	assert.Equal(t, "mail eq 'alice@contoso.com' and department eq 'R&D'", (*criteria).String())
+
+	criteria, err = registration.Where(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, criteria)
+
+	_, err = registration.Where([]string{"displayName=Alice"})
+	assert.EqualError(t, err, "tests cannot be filtered on displayName, expected one of [mail department]")
+
+	_, err = registration.Where([]string{"mail"})
+	assert.EqualError(t, err, "invalid condition 'mail', expected property=value")
+}
@@ -13,6 +13,12 @@ type Resource interface {
 // BaseResourceAPI BaseResourceAPI
 type ResourceAPI interface {
 	ConvertToResourceSlice(body []byte) []Resource
+	ConvertToResource(body []byte) Resource
 	CreateQueryParams(context cli.Context, args cli.Args) url.Values
 	CreateRequestPath(context cli.Context, args cli.Args) string
 }
+
+// DefaultColumnsResource a resource with a default set of properties to show as table columns
+type DefaultColumnsResource interface {
+	DefaultColumns() []string
+}
@@ -0,0 +1,44 @@
+package msgraph
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy how requests that were throttled (429) or hit an unavailable service (503, 504) are retried
+type RetryPolicy struct {
+	// MaxRetries the number of retries after the first attempt, zero disables retrying
+	MaxRetries int
+	// BaseDelay the wait before the first retry when the response has no Retry-After, doubled for every retry
+	BaseDelay time.Duration
+	// MaxDelay caps the wait before a retry, including the Retry-After asked for by the API
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy the retry policy used when BaseResource.RetryPolicy is nil
+var DefaultRetryPolicy = RetryPolicy{MaxRetries: 4, BaseDelay: time.Second, MaxDelay: 60 * time.Second}
+
+// Retryable reports whether a response with status should be retried
+func (p RetryPolicy) Retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Delay the wait before retry number attempt (starting at 0) of a request that got response. The
+// Retry-After header of response is honoured, otherwise the delay grows exponentially.
+func (p RetryPolicy) Delay(attempt int, response *http.Response) time.Duration {
+	delay := p.BaseDelay << uint(attempt)
+	if response != nil {
+		if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			delay = time.Duration(seconds) * time.Second
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
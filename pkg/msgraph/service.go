@@ -0,0 +1,160 @@
+package msgraph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// ObjectService the operations common to the users, groups and applications services
+type ObjectService interface {
+	List(ctx context.Context, criteria *Criteria, v interface{}) error
+	Get(ctx context.Context, id string, v interface{}) error
+	Create(ctx context.Context, body interface{}, v interface{}) error
+	Update(ctx context.Context, id string, body interface{}) error
+	Delete(ctx context.Context, id string) error
+}
+
+// UserService the operations on users, implemented by Client.Users and by fakes in tests
+type UserService interface {
+	ObjectService
+	MemberOf(ctx context.Context, id string, v interface{}) error
+}
+
+// GroupService the operations on groups and their members, implemented by Client.Groups and by fakes in tests
+type GroupService interface {
+	ObjectService
+	ListMembers(ctx context.Context, id string, v interface{}) error
+	AddMember(ctx context.Context, id string, memberID string) error
+	RemoveMember(ctx context.Context, id string, memberID string) error
+}
+
+// ApplicationService the operations on application registrations, implemented by Client.Applications and
+// by fakes in tests
+type ApplicationService interface {
+	ObjectService
+}
+
+var (
+	_ UserService        = (*UsersService)(nil)
+	_ GroupService       = (*GroupsService)(nil)
+	_ ApplicationService = (*ApplicationsService)(nil)
+)
+
+// Service the operations on one collection of the API. Objects are decoded into the values passed in,
+// typically the resource types of the resources package, and encoded from the bodies passed in.
+type Service struct {
+	base BaseResource
+	path string
+}
+
+// List decodes every object of the collection matching criteria, or all of them when criteria is nil,
+// into v, a pointer to a slice. On error v holds the objects of the pages retrieved so far.
+func (s *Service) List(ctx context.Context, criteria *Criteria, v interface{}) error {
+	var params url.Values
+	if criteria != nil {
+		params = CreateURLFilterParams(criteria)
+	}
+	return s.list(ctx, s.path, params, v)
+}
+
+func (s *Service) list(ctx context.Context, path string, params url.Values, v interface{}) error {
+	slice := reflect.ValueOf(v)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("list needs a pointer to a slice, not %T", v)
+	}
+
+	return s.base.pages(ctx, path, params, func(body []byte) error {
+		page := reflect.New(slice.Elem().Type())
+		response := struct {
+			Value interface{} `json:"value"`
+		}{page.Interface()}
+		if err := json.Unmarshal(body, &response); err != nil {
+			return fmt.Errorf("JSON unmarshalling of response body failed: %v", err)
+		}
+		slice.Elem().Set(reflect.AppendSlice(slice.Elem(), page.Elem()))
+		return nil
+	})
+}
+
+// Get decodes the object with id, or user principal name for users, into v
+func (s *Service) Get(ctx context.Context, id string, v interface{}) error {
+	body, err := s.base.Request(ctx, "GET", s.objectPath(id), nil, nil)
+	if err != nil {
+		return err
+	}
+	return decode(body, v)
+}
+
+// Create adds body to the collection and decodes the created object into v, unless v is nil
+func (s *Service) Create(ctx context.Context, body interface{}, v interface{}) error {
+	response, err := s.base.Request(ctx, "POST", s.path, nil, body)
+	if err != nil {
+		return err
+	}
+	return decode(response, v)
+}
+
+// Update sets the properties in body on the object with id
+func (s *Service) Update(ctx context.Context, id string, body interface{}) error {
+	return s.base.Update(ctx, s.objectPath(id), body)
+}
+
+// Delete deletes the object with id. Users, groups and applications are kept as deleted items for 30 days.
+func (s *Service) Delete(ctx context.Context, id string) error {
+	return s.base.Delete(ctx, s.objectPath(id))
+}
+
+func (s *Service) objectPath(id string) string {
+	return s.path + "/" + id
+}
+
+func decode(body []byte, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("JSON unmarshalling of response body failed: %v", err)
+	}
+	return nil
+}
+
+// UsersService the operations on users
+type UsersService struct {
+	Service
+}
+
+// MemberOf decodes the groups and directory roles the user is a direct member of into v, a pointer to a slice
+func (s *UsersService) MemberOf(ctx context.Context, id string, v interface{}) error {
+	return s.list(ctx, s.objectPath(id)+"/memberOf", nil, v)
+}
+
+// GroupsService the operations on groups and their members
+type GroupsService struct {
+	Service
+}
+
+// ListMembers decodes the direct members of the group into v, a pointer to a slice
+func (s *GroupsService) ListMembers(ctx context.Context, id string, v interface{}) error {
+	return s.list(ctx, s.objectPath(id)+"/members", nil, v)
+}
+
+// AddMember adds the directory object with id memberID to the group
+func (s *GroupsService) AddMember(ctx context.Context, id string, memberID string) error {
+	member := strings.TrimSuffix(s.base.baseURL(), "/") + s.base.versionedPath("/directoryObjects/"+url.PathEscape(memberID))
+	_, err := s.base.Request(ctx, "POST", s.objectPath(id)+"/members/$ref", nil, map[string]string{"@odata.id": member})
+	return err
+}
+
+// RemoveMember removes the directory object with id memberID from the group
+func (s *GroupsService) RemoveMember(ctx context.Context, id string, memberID string) error {
+	return s.base.Delete(ctx, s.objectPath(id)+"/members/"+memberID+"/$ref")
+}
+
+// ApplicationsService the operations on application registrations
+type ApplicationsService struct {
+	Service
+}
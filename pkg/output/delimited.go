@@ -0,0 +1,24 @@
+package output
+
+import (
+	"encoding/csv"
+	"io"
+
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+// WriteDelimited writes resources as delimited text with a header row, one resource per record.
+// comma is ',' for CSV and '\t' for TSV, values are quoted where needed. Slice values are joined with separator.
+func WriteDelimited(w io.Writer, resources []msgraph.Resource, fields []string, comma rune, separator string) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+
+	columns := Columns(resources, fields)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(Rows(resources, columns, separator)); err != nil {
+		return err
+	}
+	return writer.Error()
+}
@@ -0,0 +1,79 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+type testResource struct {
+	ID             string    `json:"id"`
+	DisplayName    string    `json:"displayName"`
+	BusinessPhones []string  `json:"businessPhones"`
+	Created        time.Time `json:"createdDateTime"`
+
+	AdditionalProperties msgraph.AdditionalProperties `json:"-"`
+}
+
+func (r testResource) ToString() string {
+	return r.DisplayName
+}
+
+func (r testResource) MarshalJSON() ([]byte, error) {
+	type resource testResource
+	return msgraph.MarshalResource(resource(r), r.AdditionalProperties)
+}
+
+type DelimitedTestSuite struct {
+	suite.Suite
+	resources []msgraph.Resource
+}
+
+func (suite *DelimitedTestSuite) SetupTest() {
+	suite.resources = []msgraph.Resource{
+		testResource{
+			ID:             "1",
+			DisplayName:    "Smith, Alice",
+			BusinessPhones: []string{"+64 1", "+64 2"},
+			Created:        time.Date(2020, 11, 5, 8, 17, 45, 0, time.UTC),
+			AdditionalProperties: msgraph.AdditionalProperties{
+				"department": "IT",
+			},
+		},
+		testResource{ID: "2", DisplayName: `Bob "B"`},
+	}
+}
+
+func (suite *DelimitedTestSuite) TestCSV() {
+	var buffer bytes.Buffer
+	err := WriteDelimited(&buffer, suite.resources, nil, ',', DefaultSeparator)
+
+	suite.NoError(err)
+	suite.Equal(`id,displayName,businessPhones,createdDateTime,department
+1,"Smith, Alice",+64 1;+64 2,2020-11-05T08:17:45Z,IT
+2,"Bob ""B""",,,
+`, buffer.String())
+}
+
+func (suite *DelimitedTestSuite) TestTSVFieldOrder() {
+	var buffer bytes.Buffer
+	err := WriteDelimited(&buffer, suite.resources, []string{"Department", "businessphones", "id"}, '\t', "|")
+
+	suite.NoError(err)
+	suite.Equal("department\tbusinessPhones\tid\nIT\t+64 1|+64 2\t1\n\t\t2\n", buffer.String())
+}
+
+func (suite *DelimitedTestSuite) TestFormatValue() {
+	suite.Equal("", FormatValue(nil, ","))
+	suite.Equal("", FormatValue(time.Time{}, ","))
+	suite.Equal("1500000", FormatValue(float64(1500000), ","))
+	suite.Equal(`{"a":"b"}`, FormatValue(map[string]interface{}{"a": "b"}, ","))
+	suite.Equal("a,b", FormatValue([]interface{}{"a", "b"}, ","))
+}
+
+func TestDelimitedTestSuite(t *testing.T) {
+	suite.Run(t, new(DelimitedTestSuite))
+}
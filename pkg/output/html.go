@@ -0,0 +1,74 @@
+package output
+
+import (
+	"html/template"
+	"io"
+	"time"
+
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+// Report the details shown above an HTML report
+type Report struct {
+	Title     string
+	Tenant    string
+	Generated time.Time
+}
+
+// htmlReport a self contained page: the styles and the script sorting the table by a clicked header are inline
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Report.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292e; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #d0d7de; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; cursor: pointer; user-select: none; }
+th.asc::after { content: " \25B2"; }
+th.desc::after { content: " \25BC"; }
+tr:nth-child(even) td { background: #fafbfc; }
+.meta { color: #57606a; }
+</style>
+</head>
+<body>
+<h1>{{.Report.Title}}</h1>
+<p class="meta">Generated {{.Report.Generated.Format "2006-01-02T15:04:05Z07:00"}}{{if .Report.Tenant}} for tenant {{.Report.Tenant}}{{end}}, {{len .Rows}} rows</p>
+<table>
+<thead>
+<tr>{{range .Columns}}<th>{{.}}</th>{{end}}</tr>
+</thead>
+<tbody>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+<script>
+document.querySelectorAll("th").forEach(function (header, column) {
+  header.addEventListener("click", function () {
+    var ascending = !header.classList.contains("asc");
+    document.querySelectorAll("th").forEach(function (other) { other.classList.remove("asc", "desc"); });
+    header.classList.add(ascending ? "asc" : "desc");
+    var body = document.querySelector("tbody");
+    var rows = Array.prototype.slice.call(body.rows);
+    rows.sort(function (a, b) {
+      var compared = a.cells[column].textContent.localeCompare(b.cells[column].textContent, undefined, {numeric: true});
+      return ascending ? compared : -compared;
+    });
+    rows.forEach(function (row) { body.appendChild(row); });
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// WriteHTML writes resources as a self contained HTML page with a table of columns that sorts by a
+// clicked header. Values are escaped by html/template.
+func WriteHTML(w io.Writer, resources []msgraph.Resource, columns []string, report Report) error {
+	return htmlReport.Execute(w, struct {
+		Report  Report
+		Columns []string
+		Rows    [][]string
+	}{report, columns, Rows(resources, columns, tableSeparator)})
+}
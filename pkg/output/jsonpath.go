@@ -0,0 +1,182 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+// JSONPath a kubectl style JSONPath template: text with {} delimited expressions evaluated against the JSON
+// representation of a resource. Expressions are a subset of JSONPath: the root ($ or nothing), child
+// properties (.name or ['name']), array indexes ([0], [-1]) and wildcards ([*], .*). Multiple results
+// are separated by spaces, missing properties give no result.
+type JSONPath struct {
+	segments []jsonPathSegment
+}
+
+type jsonPathSegment struct {
+	text  string
+	steps []jsonPathStep
+}
+
+type jsonPathStep struct {
+	name     string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// ParseJSONPath parses a JSONPath template such as '{.displayName} <{.mail}>'
+func ParseJSONPath(text string) (*JSONPath, error) {
+	var path JSONPath
+	for len(text) > 0 {
+		start := strings.Index(text, "{")
+		if start < 0 {
+			path.segments = append(path.segments, jsonPathSegment{text: text})
+			break
+		}
+		if start > 0 {
+			path.segments = append(path.segments, jsonPathSegment{text: text[:start]})
+		}
+		end := strings.Index(text[start:], "}")
+		if end < 0 {
+			return nil, fmt.Errorf("jsonpath: unclosed expression in %q", text)
+		}
+		steps, err := parseJSONPathSteps(strings.TrimSpace(text[start+1 : start+end]))
+		if err != nil {
+			return nil, err
+		}
+		path.segments = append(path.segments, jsonPathSegment{steps: steps})
+		text = text[start+end+1:]
+	}
+	return &path, nil
+}
+
+func parseJSONPathSteps(expression string) ([]jsonPathStep, error) {
+	var steps = []jsonPathStep{}
+	rest := strings.TrimPrefix(expression, "$")
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			rest = rest[end:]
+			switch name {
+			case "":
+				return nil, fmt.Errorf("jsonpath: empty property name in %q", expression)
+			case "*":
+				steps = append(steps, jsonPathStep{wildcard: true})
+			default:
+				steps = append(steps, jsonPathStep{name: name})
+			}
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("jsonpath: unclosed [ in %q", expression)
+			}
+			selector := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			switch {
+			case selector == "*":
+				steps = append(steps, jsonPathStep{wildcard: true})
+			case strings.HasPrefix(selector, "'") && strings.HasSuffix(selector, "'") && len(selector) > 1:
+				steps = append(steps, jsonPathStep{name: selector[1 : len(selector)-1]})
+			default:
+				index, err := strconv.Atoi(selector)
+				if err != nil {
+					return nil, fmt.Errorf("jsonpath: invalid index %q in %q", selector, expression)
+				}
+				steps = append(steps, jsonPathStep{index: index, isIndex: true})
+			}
+		default:
+			return nil, fmt.Errorf("jsonpath: unexpected %q in %q", rest[0], expression)
+		}
+	}
+	return steps, nil
+}
+
+// Execute writes the template evaluated against data, a value decoded from JSON
+func (p *JSONPath) Execute(w io.Writer, data interface{}) error {
+	for _, segment := range p.segments {
+		text := segment.text
+		if segment.steps != nil {
+			var values []string
+			for _, value := range evaluateJSONPath(segment.steps, data) {
+				values = append(values, FormatValue(value, " "))
+			}
+			text = strings.Join(values, " ")
+		}
+		if _, err := io.WriteString(w, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func evaluateJSONPath(steps []jsonPathStep, data interface{}) []interface{} {
+	values := []interface{}{data}
+	for _, step := range steps {
+		var next []interface{}
+		for _, value := range values {
+			switch typed := value.(type) {
+			case map[string]interface{}:
+				if step.wildcard {
+					for _, key := range sortedKeys(typed) {
+						next = append(next, typed[key])
+					}
+				} else if child, found := typed[step.name]; found && !step.isIndex {
+					next = append(next, child)
+				}
+			case []interface{}:
+				if step.wildcard {
+					next = append(next, typed...)
+				} else if step.isIndex {
+					index := step.index
+					if index < 0 {
+						index += len(typed)
+					}
+					if index >= 0 && index < len(typed) {
+						next = append(next, typed[index])
+					}
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+// WriteJSONPath evaluates path against each of resources, each followed by a new line, or once against
+// the array of resources when collection is set
+func WriteJSONPath(w io.Writer, path *JSONPath, resources []msgraph.Resource, collection bool) error {
+	if collection {
+		if resources == nil {
+			resources = []msgraph.Resource{}
+		}
+		data, err := genericValue(resources)
+		if err != nil {
+			return err
+		}
+		return path.Execute(w, data)
+	}
+	for _, resource := range resources {
+		data, err := genericValue(resource)
+		if err != nil {
+			return err
+		}
+		if err := path.Execute(w, data); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
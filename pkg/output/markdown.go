@@ -0,0 +1,40 @@
+package output
+
+import (
+	"io"
+	"strings"
+
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+// markdownEscaper escapes the characters that would break a GitHub flavoured markdown table cell or be
+// rendered as HTML
+var markdownEscaper = strings.NewReplacer("|", `\|`, "&", "&amp;", "<", "&lt;", ">", "&gt;", "\r\n", "<br>", "\n", "<br>")
+
+// WriteMarkdown writes resources as a GitHub flavoured markdown table of columns
+func WriteMarkdown(w io.Writer, resources []msgraph.Resource, columns []string) error {
+	if len(columns) == 0 {
+		return nil
+	}
+
+	var lines []string
+	var separators = make([]string, len(columns))
+	for index := range separators {
+		separators[index] = "---"
+	}
+	lines = append(lines, markdownRow(columns), markdownRow(separators))
+	for _, row := range Rows(resources, columns, tableSeparator) {
+		lines = append(lines, markdownRow(row))
+	}
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+func markdownRow(values []string) string {
+	var cells = make([]string, len(values))
+	for index, value := range values {
+		cells[index] = markdownEscaper.Replace(value)
+	}
+	return "| " + strings.Join(cells, " | ") + " |"
+}
@@ -0,0 +1,57 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+// WriteNDJSON writes each of resources as a JSON object on its own line. Without fields the whole
+// resource is written, otherwise only the fields given in their order.
+func WriteNDJSON(w io.Writer, resources []msgraph.Resource, fields []string) error {
+	if len(fields) > 0 {
+		fields = Columns(resources, fields)
+	}
+	for _, resource := range resources {
+		var line []byte
+		var err error
+		if len(fields) == 0 {
+			line, err = json.Marshal(resource)
+		} else {
+			line, err = marshalFields(Properties(resource, fields))
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// marshalFields marshals fields as a JSON object keeping their order
+func marshalFields(fields []msgraph.Field) ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for index, field := range fields {
+		if index > 0 {
+			buffer.WriteByte(',')
+		}
+		name, err := json.Marshal(field.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(name)
+		buffer.WriteByte(':')
+		buffer.Write(value)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
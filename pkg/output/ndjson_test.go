@@ -0,0 +1,24 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+func TestWriteNDJSON(t *testing.T) {
+	resources := []msgraph.Resource{
+		testResource{ID: "1", DisplayName: "Alice", AdditionalProperties: msgraph.AdditionalProperties{"department": "IT"}},
+		testResource{ID: "2", DisplayName: "Bob"},
+	}
+
+	var buffer bytes.Buffer
+	err := WriteNDJSON(&buffer, resources, []string{"Department", "displayname"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"department":"IT","displayName":"Alice"}
+{"department":null,"displayName":"Bob"}
+`, buffer.String())
+}
@@ -0,0 +1,133 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+// DefaultSeparator the separator used to join slice values into a single column
+const DefaultSeparator = ";"
+
+// Columns the property names to render for resources. The names given with --fields are used in their order,
+// resolved to the JSON property names of the resources, otherwise every property of the resources in the order
+// they are first seen.
+func Columns(resources []msgraph.Resource, fields []string) []string {
+	if len(fields) > 0 {
+		var columns = make([]string, len(fields))
+		for index, name := range fields {
+			columns[index] = name
+			for _, resource := range resources {
+				if field, found := msgraph.FindField(resource, name); found {
+					columns[index] = field.Name
+					break
+				}
+			}
+		}
+		return columns
+	}
+
+	var columns []string
+	seen := map[string]bool{}
+	for _, resource := range resources {
+		for _, field := range msgraph.ResourceFields(resource) {
+			if !seen[field.Name] {
+				seen[field.Name] = true
+				columns = append(columns, field.Name)
+			}
+		}
+	}
+	return columns
+}
+
+// Rows the formatted values of columns for each of resources
+func Rows(resources []msgraph.Resource, columns []string, separator string) [][]string {
+	var rows = make([][]string, len(resources))
+	for index, resource := range resources {
+		row := make([]string, len(columns))
+		for column, field := range msgraph.SelectFields(resource, columns) {
+			row[column] = FormatValue(field.Value, separator)
+		}
+		rows[index] = row
+	}
+	return rows
+}
+
+// FormatValue formats a property value as a single line of text. Times are written in ISO-8601 and zero
+// times as empty, slice elements are joined with separator and objects are written as JSON.
+func FormatValue(value interface{}, separator string) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case bool:
+		return strconv.FormatBool(typed)
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case time.Time:
+		if typed.IsZero() {
+			return ""
+		}
+		return typed.Format(time.RFC3339)
+	case fmt.Stringer:
+		return typed.String()
+	}
+
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if reflected.IsNil() {
+			return ""
+		}
+		return FormatValue(reflected.Elem().Interface(), separator)
+	case reflect.Slice, reflect.Array:
+		var values = make([]string, reflected.Len())
+		for index := range values {
+			values[index] = FormatValue(reflected.Index(index).Interface(), separator)
+		}
+		return strings.Join(values, separator)
+	case reflect.Map, reflect.Struct:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprintf("%v", value)
+		}
+		return string(data)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// Properties the properties of resource to write as an object: the fields given, or every property of
+// resource when no fields are given
+func Properties(resource msgraph.Resource, fields []string) []msgraph.Field {
+	if len(fields) == 0 {
+		return msgraph.ResourceFields(resource)
+	}
+	return msgraph.SelectFields(resource, fields)
+}
+
+// genericValue value as decoded from its JSON representation, so nested objects use JSON property names
+func genericValue(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	err = json.Unmarshal(data, &generic)
+	return generic, err
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	var keys []string
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,44 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+var reportResources = []msgraph.Resource{
+	testResource{ID: "1", DisplayName: "Alice | <b>Admins</b>", BusinessPhones: []string{"+64 1", "+64 2"}},
+	testResource{ID: "2", DisplayName: "Bob\nSmith"},
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buffer bytes.Buffer
+	err := WriteMarkdown(&buffer, reportResources, []string{"id", "displayName", "businessPhones"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `| id | displayName | businessPhones |
+| --- | --- | --- |
+| 1 | Alice \| &lt;b&gt;Admins&lt;/b&gt; | +64 1, +64 2 |
+| 2 | Bob<br>Smith |  |
+`, buffer.String())
+}
+
+func TestWriteHTML(t *testing.T) {
+	var buffer bytes.Buffer
+	err := WriteHTML(&buffer, reportResources, []string{"id", "displayName"}, Report{
+		Title:     "Access <review>",
+		Tenant:    "contoso",
+		Generated: time.Date(2020, 11, 5, 8, 17, 45, 0, time.UTC),
+	})
+
+	assert.NoError(t, err)
+	html := buffer.String()
+	assert.Contains(t, html, "<title>Access &lt;review&gt;</title>")
+	assert.Contains(t, html, "Generated 2020-11-05T08:17:45Z for tenant contoso, 2 rows")
+	assert.Contains(t, html, "<tr><th>id</th><th>displayName</th></tr>")
+	assert.Contains(t, html, "<td>Alice | &lt;b&gt;Admins&lt;/b&gt;</td>")
+	assert.NotContains(t, html, "<b>Admins</b>")
+}
@@ -0,0 +1,119 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rodaine/table"
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+const (
+	// tablePadding the spaces between table columns
+	tablePadding = 2
+	// minColumnWidth columns are not truncated below this width
+	minColumnWidth = 6
+	// tableSeparator the separator joining slice values in a table cell
+	tableSeparator = ", "
+)
+
+// TableColumns the columns of a table of resources: the fields given in their order, otherwise the default
+// columns of the resources or of their registration, or every property when wide is set or the resources
+// have no default columns
+func TableColumns(resources []msgraph.Resource, fields []string, wide bool) []string {
+	if len(fields) > 0 || wide || len(resources) == 0 {
+		return Columns(resources, fields)
+	}
+	if defaults := msgraph.DefaultColumns(resources[0]); len(defaults) > 0 {
+		return defaults
+	}
+	return Columns(resources, nil)
+}
+
+// WriteTable writes resources as a table with a header row. When width is positive the widest columns are
+// truncated so each line fits in width characters.
+func WriteTable(w io.Writer, resources []msgraph.Resource, columns []string, width int) error {
+	if len(columns) == 0 {
+		return nil
+	}
+
+	rows := Rows(resources, columns, tableSeparator)
+	for _, row := range rows {
+		for index, value := range row {
+			row[index] = strings.Join(strings.Fields(value), " ")
+		}
+	}
+
+	if width > 0 {
+		truncate(columns, rows, width)
+	}
+
+	var headers = make([]interface{}, len(columns))
+	for index, column := range columns {
+		headers[index] = column
+	}
+	tbl := table.New(headers...).WithWriter(w).WithPadding(tablePadding)
+	for _, row := range rows {
+		var values = make([]interface{}, len(row))
+		for index, value := range row {
+			values[index] = value
+		}
+		tbl.AddRow(values...)
+	}
+	tbl.Print()
+	return nil
+}
+
+// truncate shortens the widest cells, and the headers, until a line of the table fits in width
+func truncate(headers []string, rows [][]string, width int) {
+	var widths = make([]int, len(headers))
+	for index, header := range headers {
+		widths[index] = utf8.RuneCountInString(header)
+	}
+	for _, row := range rows {
+		for index, value := range row {
+			if length := utf8.RuneCountInString(value); length > widths[index] {
+				widths[index] = length
+			}
+		}
+	}
+
+	// every column, the last included, is followed by the padding
+	total := tablePadding * len(widths)
+	for _, columnWidth := range widths {
+		total += columnWidth
+	}
+	for total > width {
+		widest := 0
+		for index := range widths {
+			if widths[index] > widths[widest] {
+				widest = index
+			}
+		}
+		if widths[widest] <= minColumnWidth {
+			break
+		}
+		widths[widest]--
+		total--
+	}
+
+	for index := range headers {
+		headers[index] = ellipsis(headers[index], widths[index])
+	}
+	for _, row := range rows {
+		for index := range row {
+			row[index] = ellipsis(row[index], widths[index])
+		}
+	}
+}
+
+// ellipsis value shortened to width characters, ending with an ellipsis when shortened
+func ellipsis(value string, width int) string {
+	if utf8.RuneCountInString(value) <= width {
+		return value
+	}
+	runes := []rune(value)
+	return fmt.Sprintf("%s…", string(runes[:width-1]))
+}
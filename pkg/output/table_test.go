@@ -0,0 +1,90 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+type defaultColumnsResource struct {
+	testResource
+}
+
+func (r defaultColumnsResource) DefaultColumns() []string {
+	return []string{"displayName", "id"}
+}
+
+type TableTestSuite struct {
+	suite.Suite
+	resources []msgraph.Resource
+}
+
+func (suite *TableTestSuite) SetupTest() {
+	suite.resources = []msgraph.Resource{
+		testResource{
+			ID:             "1",
+			DisplayName:    "Alice",
+			BusinessPhones: []string{"+64 1", "+64 2"},
+			Created:        time.Date(2020, 11, 5, 8, 17, 45, 0, time.UTC),
+		},
+		testResource{ID: "2", DisplayName: "Bob"},
+	}
+}
+
+func (suite *TableTestSuite) lines(buffer bytes.Buffer) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n") {
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+	return lines
+}
+
+func (suite *TableTestSuite) TestFieldOrder() {
+	columns := TableColumns(suite.resources, []string{"BUSINESSPHONES", "displayname", "createdDateTime"}, false)
+	suite.Equal([]string{"businessPhones", "displayName", "createdDateTime"}, columns)
+
+	var buffer bytes.Buffer
+	suite.NoError(WriteTable(&buffer, suite.resources, columns, 0))
+	suite.Equal([]string{
+		"businessPhones  displayName  createdDateTime",
+		"+64 1, +64 2    Alice        2020-11-05T08:17:45Z",
+		"                Bob",
+	}, suite.lines(buffer))
+}
+
+func (suite *TableTestSuite) TestDefaultColumns() {
+	resources := []msgraph.Resource{defaultColumnsResource{testResource{ID: "1", DisplayName: "Alice"}}}
+
+	suite.Equal([]string{"displayName", "id"}, TableColumns(resources, nil, false))
+	suite.Equal([]string{"id", "displayName", "businessPhones", "createdDateTime"}, TableColumns(resources, nil, true))
+	suite.Equal([]string{"id", "displayName", "businessPhones", "createdDateTime"}, TableColumns(suite.resources, nil, false))
+}
+
+func (suite *TableTestSuite) TestNoResources() {
+	var buffer bytes.Buffer
+	suite.NoError(WriteTable(&buffer, nil, TableColumns(nil, []string{"id"}, false), 0))
+	suite.Equal([]string{"id"}, suite.lines(buffer))
+
+	buffer.Reset()
+	suite.NoError(WriteTable(&buffer, nil, TableColumns(nil, nil, false), 0))
+	suite.Empty(buffer.String())
+}
+
+func (suite *TableTestSuite) TestTruncate() {
+	resources := []msgraph.Resource{testResource{ID: "1", DisplayName: strings.Repeat("x", 40)}}
+
+	var buffer bytes.Buffer
+	suite.NoError(WriteTable(&buffer, resources, []string{"id", "displayName"}, 20))
+	suite.Equal([]string{
+		"id  displayName",
+		"1   xxxxxxxxxxxxx…",
+	}, suite.lines(buffer))
+}
+
+func TestTableTestSuite(t *testing.T) {
+	suite.Run(t, new(TableTestSuite))
+}
@@ -0,0 +1,102 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+// TemplateFuncs the helper functions available to output templates: join (join ", " .BusinessPhones),
+// date to format a time or ISO-8601 string (date "2006-01-02" .CreatedDateTime), pad and padLeft to pad
+// with spaces to a width (pad 20 .DisplayName), field to get a property by JSON or Go name including
+// additional properties (field "department" .) and json.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"join":    join,
+		"date":    date,
+		"pad":     func(width int, value interface{}) string { return fmt.Sprintf("%-*s", width, FormatValue(value, " ")) },
+		"padLeft": func(width int, value interface{}) string { return fmt.Sprintf("%*s", width, FormatValue(value, " ")) },
+		"field":   field,
+		"json":    toJSON,
+	}
+}
+
+// NewTemplate parses text as an output template with the helper functions of TemplateFuncs
+func NewTemplate(text string) (*template.Template, error) {
+	return template.New("output").Funcs(TemplateFuncs()).Parse(text)
+}
+
+// WriteTemplate executes tmpl for each of resources, each followed by a new line, or once with the
+// slice of resources when collection is set
+func WriteTemplate(w io.Writer, tmpl *template.Template, resources []msgraph.Resource, collection bool) error {
+	if collection {
+		return tmpl.Execute(w, resources)
+	}
+	for _, resource := range resources {
+		if err := tmpl.Execute(w, resource); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func join(separator string, value interface{}) string {
+	reflected := reflect.ValueOf(value)
+	if reflected.Kind() != reflect.Slice && reflected.Kind() != reflect.Array {
+		return FormatValue(value, separator)
+	}
+	var values = make([]string, reflected.Len())
+	for index := range values {
+		values[index] = FormatValue(reflected.Index(index).Interface(), separator)
+	}
+	return strings.Join(values, separator)
+}
+
+func date(layout string, value interface{}) (string, error) {
+	switch typed := value.(type) {
+	case time.Time:
+		if typed.IsZero() {
+			return "", nil
+		}
+		return typed.Format(layout), nil
+	case *time.Time:
+		if typed == nil {
+			return "", nil
+		}
+		return date(layout, *typed)
+	case string:
+		if typed == "" {
+			return "", nil
+		}
+		parsed, err := time.Parse(time.RFC3339, typed)
+		if err != nil {
+			return "", err
+		}
+		return parsed.Format(layout), nil
+	case nil:
+		return "", nil
+	}
+	return "", fmt.Errorf("date: unsupported value %v", value)
+}
+
+func field(name string, resource msgraph.Resource) interface{} {
+	found, _ := msgraph.FindField(resource, name)
+	if found.Value == nil {
+		return ""
+	}
+	return found.Value
+}
+
+func toJSON(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	return string(data), err
+}
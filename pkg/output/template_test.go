@@ -0,0 +1,75 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+type TemplateTestSuite struct {
+	suite.Suite
+	resources []msgraph.Resource
+}
+
+func (suite *TemplateTestSuite) SetupTest() {
+	suite.resources = []msgraph.Resource{
+		testResource{
+			ID:                   "1",
+			DisplayName:          "Alice",
+			BusinessPhones:       []string{"+64 1", "+64 2"},
+			Created:              time.Date(2020, 11, 5, 8, 17, 45, 0, time.UTC),
+			AdditionalProperties: msgraph.AdditionalProperties{"department": "IT"},
+		},
+		testResource{ID: "2", DisplayName: "Bob"},
+	}
+}
+
+func (suite *TemplateTestSuite) TestPerResource() {
+	tmpl, err := NewTemplate(`{{pad 6 .DisplayName}}|{{padLeft 3 .ID}} {{join ", " .BusinessPhones}} {{date "2006-01-02" .Created}} {{field "department" .}}`)
+	suite.NoError(err)
+
+	var buffer bytes.Buffer
+	suite.NoError(WriteTemplate(&buffer, tmpl, suite.resources, false))
+	suite.Equal("Alice |  1 +64 1, +64 2 2020-11-05 IT\nBob   |  2   \n", buffer.String())
+}
+
+func (suite *TemplateTestSuite) TestCollection() {
+	tmpl, err := NewTemplate(`{{len .}}:{{range .}} {{.ID}}{{end}}`)
+	suite.NoError(err)
+
+	var buffer bytes.Buffer
+	suite.NoError(WriteTemplate(&buffer, tmpl, suite.resources, true))
+	suite.Equal("2: 1 2", buffer.String())
+}
+
+func (suite *TemplateTestSuite) TestJSONPath() {
+	path, err := ParseJSONPath(`{.id} {$.displayName} <{.businessPhones[*]}> {.businessPhones[-1]} {['department']}{.missing}`)
+	suite.NoError(err)
+
+	var buffer bytes.Buffer
+	suite.NoError(WriteJSONPath(&buffer, path, suite.resources, false))
+	suite.Equal("1 Alice <+64 1 +64 2> +64 2 IT\n2 Bob <>  \n", buffer.String())
+}
+
+func (suite *TemplateTestSuite) TestJSONPathCollection() {
+	path, err := ParseJSONPath(`{[*].displayName}`)
+	suite.NoError(err)
+
+	var buffer bytes.Buffer
+	suite.NoError(WriteJSONPath(&buffer, path, suite.resources, true))
+	suite.Equal("Alice Bob", buffer.String())
+}
+
+func (suite *TemplateTestSuite) TestJSONPathInvalid() {
+	for _, text := range []string{"{.id", "{.a[x]}", "{.a..b}", "{a}"} {
+		_, err := ParseJSONPath(text)
+		suite.Error(err, text)
+	}
+}
+
+func TestTemplateTestSuite(t *testing.T) {
+	suite.Run(t, new(TemplateTestSuite))
+}
@@ -0,0 +1,15 @@
+package output
+
+import (
+	"os"
+	"strconv"
+)
+
+// TerminalWidth the width of the terminal file is connected to, from the COLUMNS environment variable or
+// the terminal itself. Zero when file is not a terminal.
+func TerminalWidth(file *os.File) int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return terminalWidth(file)
+}
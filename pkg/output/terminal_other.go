@@ -0,0 +1,11 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package output
+
+import "os"
+
+// terminalWidth is not detected on this platform, COLUMNS sets it
+func terminalWidth(file *os.File) int {
+	return 0
+}
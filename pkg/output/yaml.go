@@ -0,0 +1,80 @@
+package output
+
+import (
+	"io"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+// WriteYAML writes resources as a YAML sequence of mappings keyed by JSON property names. Without fields
+// every property is written, otherwise only the fields given in their order.
+func WriteYAML(w io.Writer, resources []msgraph.Resource, fields []string) error {
+	if len(fields) > 0 {
+		fields = Columns(resources, fields)
+	}
+	document := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	if len(resources) == 0 {
+		document.Style = yaml.FlowStyle
+	}
+	for _, resource := range resources {
+		mapping := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, field := range Properties(resource, fields) {
+			value, err := genericValue(field.Value)
+			if err != nil {
+				return err
+			}
+			mapping.Content = append(mapping.Content, yamlScalar("!!str", field.Name), yamlNode(value))
+		}
+		document.Content = append(document.Content, mapping)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// yamlNode the YAML node of a value decoded from JSON
+func yamlNode(value interface{}) *yaml.Node {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		var keys []string
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			node.Content = append(node.Content, yamlScalar("!!str", key), yamlNode(typed[key]))
+		}
+		return node
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if len(typed) == 0 {
+			node.Style = yaml.FlowStyle
+		}
+		for _, item := range typed {
+			node.Content = append(node.Content, yamlNode(item))
+		}
+		return node
+	case string:
+		return yamlScalar("!!str", typed)
+	case bool:
+		return yamlScalar("!!bool", strconv.FormatBool(typed))
+	case float64:
+		if typed == float64(int64(typed)) {
+			return yamlScalar("!!int", strconv.FormatInt(int64(typed), 10))
+		}
+		return yamlScalar("!!float", strconv.FormatFloat(typed, 'g', -1, 64))
+	}
+	return yamlScalar("!!null", "null")
+}
+
+func yamlScalar(tag string, value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+}
@@ -0,0 +1,39 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+func TestWriteYAML(t *testing.T) {
+	resources := []msgraph.Resource{testResource{
+		ID:             "1",
+		DisplayName:    "Alice",
+		BusinessPhones: []string{"+64 1"},
+		AdditionalProperties: msgraph.AdditionalProperties{
+			"onPremisesExtensionAttributes": map[string]interface{}{"extensionAttribute1": "cc"},
+			"employeeId":                    "007",
+		},
+	}}
+
+	var buffer bytes.Buffer
+	err := WriteYAML(&buffer, resources, []string{"DisplayName", "businessPhones", "employeeId", "onPremisesExtensionAttributes"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `- displayName: Alice
+  businessPhones:
+  - +64 1
+  employeeId: "007"
+  onPremisesExtensionAttributes:
+    extensionAttribute1: cc
+`, buffer.String())
+}
+
+func TestWriteYAMLEmpty(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.NoError(t, WriteYAML(&buffer, nil, nil))
+	assert.Equal(t, "[]\n", buffer.String())
+}
@@ -0,0 +1,208 @@
+// Package recording records the requests made to the Graph API as fixture files, with credentials and
+// personal data redacted, and replays them without network access
+package recording
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"westpac.co.nz/msgraph/pkg/redact"
+)
+
+// Fixture a recorded request and its response
+type Fixture struct {
+	Request  FixtureRequest  `json:"request"`
+	Response FixtureResponse `json:"response"`
+}
+
+// FixtureRequest a recorded request. URL is relative to the host, the host is not used to match requests, and
+// has its personal data replaced as by redact.Redactor.URL.
+type FixtureRequest struct {
+	Method string          `json:"method"`
+	URL    string          `json:"url"`
+	Header http.Header     `json:"header,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// FixtureResponse a recorded response
+type FixtureResponse struct {
+	Status int             `json:"status"`
+	Header http.Header     `json:"header,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// key identifies the requests a fixture answers
+func (r FixtureRequest) key() string {
+	return r.Method + " " + r.URL
+}
+
+// Recorder an http.RoundTripper writing every request it sends, and its response, to a fixture file
+type Recorder struct {
+	directory string
+	next      http.RoundTripper
+	redactor  redact.Redactor
+
+	mutex    sync.Mutex
+	sequence int
+}
+
+// NewRecorder records the requests sent through next in directory, which is created when missing, with
+// the credential headers and personal data removed by redactor
+func NewRecorder(directory string, next http.RoundTripper, redactor redact.Redactor) (*Recorder, error) {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, err
+	}
+	return &Recorder{directory: directory, next: next, redactor: redactor}, nil
+}
+
+// RoundTrip sends req and records it with its response
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		requestBody, err = ioutil.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+	fixture := Fixture{
+		Request: FixtureRequest{
+			Method: req.Method,
+			URL:    r.redactor.URL(req.URL),
+			Header: r.redactor.Header(req.Header),
+			Body:   r.redactor.Body(req.URL, requestBody),
+		},
+		Response: FixtureResponse{
+			Status: resp.StatusCode,
+			Header: r.redactor.Header(resp.Header),
+			Body:   r.redactor.Body(req.URL, responseBody),
+		},
+	}
+	// the length changes with redaction
+	fixture.Response.Header.Del("Content-Length")
+	return resp, r.write(fixture)
+}
+
+var unsafeFileCharacters = regexp.MustCompile(`[^A-Za-z0-9.]+`)
+
+func (r *Recorder) write(fixture Fixture) error {
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(fixture); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.sequence++
+	name := strings.Trim(unsafeFileCharacters.ReplaceAllString(strings.SplitN(fixture.Request.URL, "?", 2)[0], "-"), "-")
+	if len(name) > 80 {
+		name = name[:80]
+	}
+	file := filepath.Join(r.directory, fmt.Sprintf("%04d-%s-%s.json", r.sequence, fixture.Request.Method, name))
+	return ioutil.WriteFile(file, data.Bytes(), 0644)
+}
+
+// Replayer an http.RoundTripper answering requests from the fixtures recorded by a Recorder, without
+// network access. Identical requests are answered with their fixtures in recorded order, the last one repeating.
+type Replayer struct {
+	redactor redact.Redactor
+
+	mutex     sync.Mutex
+	responses map[string][]FixtureResponse
+}
+
+// NewReplayer loads the fixtures in directory. redactor redacts like the one the fixtures were recorded with,
+// requests are matched with their URLs redacted the same way.
+func NewReplayer(directory string, redactor redact.Redactor) (*Replayer, error) {
+	files, err := filepath.Glob(filepath.Join(directory, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no fixtures in %s", directory)
+	}
+	sort.Strings(files)
+
+	replayer := &Replayer{redactor: redactor, responses: map[string][]FixtureResponse{}}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var fixture Fixture
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			return nil, fmt.Errorf("fixture %s: %v", file, err)
+		}
+		key := fixture.Request.key()
+		replayer.responses[key] = append(replayer.responses[key], fixture.Response)
+	}
+	return replayer, nil
+}
+
+// RoundTrip answers req with its recorded response, or an error when none was recorded
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	key := FixtureRequest{Method: req.Method, URL: r.redactor.URL(req.URL)}.key()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	responses := r.responses[key]
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("no fixture recorded for %s", key)
+	}
+	response := responses[0]
+	if len(responses) > 1 {
+		r.responses[key] = responses[1:]
+	}
+
+	body := []byte(response.Body)
+	var text string
+	if err := json.Unmarshal(body, &text); err == nil {
+		body = []byte(text)
+	}
+
+	header := response.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", response.Status, http.StatusText(response.Status)),
+		StatusCode:    response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
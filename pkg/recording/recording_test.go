@@ -0,0 +1,174 @@
+package recording
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"westpac.co.nz/msgraph/pkg/redact"
+)
+
+type RecordingTestSuite struct {
+	suite.Suite
+	server    *httptest.Server
+	directory string
+}
+
+func (suite *RecordingTestSuite) SetupTest() {
+	suite.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		switch {
+		case r.URL.Path == "/token":
+			w.Write([]byte(`{"access_token":"eyJ0eXAi","token_type":"Bearer"}`))
+			return
+		case r.URL.Path == "/v1.0/groups":
+			w.Write([]byte(`{"value":[{"id":"2","displayName":"Retail","mail":"retail@contoso.com"}]}`))
+			return
+		case strings.HasSuffix(r.URL.Path, "/owners"):
+			w.Write([]byte(`{"value":[{"@odata.type":"#microsoft.graph.user","id":"1","displayName":"Adele Vance"},
+				{"@odata.type":"#microsoft.graph.servicePrincipal","id":"3","displayName":"Retail Dashboard"}]}`))
+			return
+		}
+		w.Write([]byte(`{"value":[{"id":"1","displayName":"Adele Vance","businessPhones":["+1 425 555 0109"],"jobTitle":"Manager"}]}`))
+	}))
+	suite.directory = suite.T().TempDir()
+}
+
+func (suite *RecordingTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+var redactor = redact.New(redact.DefaultProperties, redact.Scope{
+	Collections: []string{"users"},
+	ODataType:   "#microsoft.graph.user",
+	Properties:  []string{"displayName", "businessPhones", "userPrincipalName", "mail"},
+})
+
+func (suite *RecordingTestSuite) record() {
+	recorder, err := NewRecorder(suite.directory, http.DefaultTransport, redactor)
+	suite.Require().NoError(err)
+	client := &http.Client{Transport: recorder}
+
+	request, _ := http.NewRequest("GET", suite.server.URL+"/v1.0/users?$top=1", nil)
+	request.Header.Set("Authorization", "Bearer eyJ0eXAi")
+	response, err := client.Do(request)
+	suite.Require().NoError(err)
+	body, _ := ioutil.ReadAll(response.Body)
+	suite.Contains(string(body), "Adele Vance", "the caller gets the response unredacted")
+
+	_, err = client.PostForm(suite.server.URL+"/token", url.Values{"client_id": {"c"}, "client_secret": {"s3cr3t"}})
+	suite.Require().NoError(err)
+}
+
+func (suite *RecordingTestSuite) TestRecordRedacts() {
+	suite.record()
+
+	files, _ := filepath.Glob(filepath.Join(suite.directory, "*.json"))
+	suite.Require().Len(files, 2)
+	suite.Equal("0001-GET-v1.0-users.json", filepath.Base(files[0]))
+	suite.Equal("0002-POST-token.json", filepath.Base(files[1]))
+
+	var recorded string
+	for _, file := range files {
+		data, _ := ioutil.ReadFile(file)
+		recorded += string(data)
+	}
+	for _, secret := range []string{"eyJ0eXAi", "s3cr3t", "session=secret", "Adele Vance", "+1 425 555 0109"} {
+		suite.NotContains(recorded, secret)
+	}
+	suite.Contains(recorded, `"jobTitle": "Manager"`)
+	suite.Contains(recorded, "client_id=c&client_secret=REDACTED")
+}
+
+func (suite *RecordingTestSuite) TestReplay() {
+	suite.record()
+	suite.server.Close()
+
+	replayer, err := NewReplayer(suite.directory, redactor)
+	suite.Require().NoError(err)
+	client := &http.Client{Transport: replayer}
+
+	response, err := client.Get("http://replay.invalid/v1.0/users?$top=1")
+	suite.Require().NoError(err)
+	suite.Equal(http.StatusOK, response.StatusCode)
+	suite.Equal("application/json", response.Header.Get("Content-Type"))
+	body, _ := ioutil.ReadAll(response.Body)
+	suite.Contains(string(body), `"displayName": "REDACTED"`)
+	suite.True(strings.Contains(string(body), `"jobTitle": "Manager"`))
+
+	_, err = client.Get("http://replay.invalid/v1.0/groups")
+	suite.Error(err)
+}
+
+func (suite *RecordingTestSuite) TestRecordRedactsURLs() {
+	recorder, err := NewRecorder(suite.directory, http.DefaultTransport, redactor)
+	suite.Require().NoError(err)
+	client := &http.Client{Transport: recorder}
+
+	for _, path := range []string{
+		"/v1.0/users/megan_fabrikam.com%23EXT%23@contoso.onmicrosoft.com",
+		"/v1.0/users?$filter=" + url.QueryEscape("userPrincipalName eq 'adele@contoso.com' and jobTitle eq 'Manager'"),
+		"/v1.0/users?$filter=" + url.QueryEscape("startswith(displayName,'Adele')"),
+	} {
+		_, err := client.Get(suite.server.URL + path)
+		suite.Require().NoError(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(suite.directory, "*.json"))
+	suite.Require().Len(files, 3)
+	var recorded string
+	for _, file := range files {
+		data, _ := ioutil.ReadFile(file)
+		recorded += filepath.Base(file) + string(data)
+	}
+	for _, personal := range []string{"megan", "fabrikam", "adele", "Adele"} {
+		suite.NotContains(recorded, personal)
+	}
+	suite.Contains(recorded, "Manager", "literals of other properties are kept")
+
+	replayer, err := NewReplayer(suite.directory, redactor)
+	suite.Require().NoError(err)
+	response, err := (&http.Client{Transport: replayer}).Get("http://replay.invalid/v1.0/users?$filter=" +
+		url.QueryEscape("userPrincipalName eq 'adele@contoso.com' and jobTitle eq 'Manager'"))
+	suite.Require().NoError(err)
+	suite.Equal(http.StatusOK, response.StatusCode, "requests are matched with their redacted URL")
+}
+
+func (suite *RecordingTestSuite) TestRecordRedactsPerType() {
+	recorder, err := NewRecorder(suite.directory, http.DefaultTransport, redactor)
+	suite.Require().NoError(err)
+	client := &http.Client{Transport: recorder}
+
+	for _, path := range []string{
+		"/v1.0/users?$filter=" + url.QueryEscape("startswith(displayName,'Adele')"),
+		"/v1.0/groups?$filter=" + url.QueryEscape("startswith(displayName,'Retail')"),
+		"/v1.0/groups/2/owners",
+	} {
+		_, err := client.Get(suite.server.URL + path)
+		suite.Require().NoError(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(suite.directory, "*.json"))
+	suite.Require().Len(files, 3)
+	var recorded []string
+	for _, file := range files {
+		data, _ := ioutil.ReadFile(file)
+		recorded = append(recorded, string(data))
+	}
+	suite.NotContains(recorded[0], "Adele")
+	suite.Contains(recorded[1], "startswith%28displayName%2C%27Retail%27%29", "groups are filtered on their names as they are")
+	suite.Contains(recorded[1], `"displayName": "Retail"`, "the names of groups are not personal data")
+	suite.Contains(recorded[1], `"mail": "retail@contoso.com"`)
+	suite.NotContains(recorded[2], "Adele", "users among the owners are redacted")
+	suite.Contains(recorded[2], `"displayName": "Retail Dashboard"`)
+}
+
+func TestRecordingTestSuite(t *testing.T) {
+	suite.Run(t, new(RecordingTestSuite))
+}
@@ -0,0 +1,256 @@
+// Package redact removes credentials and personal data from HTTP headers and bodies before they are
+// logged or recorded
+package redact
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Redacted the value written in place of redacted headers and properties
+const Redacted = "REDACTED"
+
+// Headers headers that carry credentials
+var Headers = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Identity-Header"}
+
+// DefaultProperties JSON properties and form values redacted from every body: secrets and personal data
+// the resource types do not declare
+var DefaultProperties = []string{
+	"access_token", "refresh_token", "id_token", "client_secret", "client_assertion", "password", "secretText",
+	"employeeId", "otherMails", "proxyAddresses", "imAddresses", "streetAddress", "faxNumber",
+	"onPremisesSamAccountName", "onPremisesUserPrincipalName",
+}
+
+// Scope personal data properties of a resource type, only redacted from the objects of that type. A
+// displayName is personal data of a user but not of a group.
+type Scope struct {
+	// Collections the URL path segments of the collections of the type, e.g. users or appRoleAssignments
+	Collections []string
+	// ODataType the @odata.type of the objects of the type in mixed collections, e.g. #microsoft.graph.user
+	ODataType string
+	// Properties the JSON properties redacted from the objects of the type
+	Properties []string
+}
+
+// Redactor redacts the credential Headers and the body properties it was created with
+type Redactor struct {
+	properties map[string]bool
+	// collections and types the properties of the scopes by collection and @odata.type
+	collections map[string]map[string]bool
+	types       map[string]map[string]bool
+}
+
+// New a Redactor of the JSON properties and form values called one of properties, and of the properties of
+// scopes in the objects of their type, ignoring case
+func New(properties []string, scopes ...Scope) Redactor {
+	redactor := Redactor{properties: names(properties), collections: map[string]map[string]bool{}, types: map[string]map[string]bool{}}
+	for _, scope := range scopes {
+		scoped := names(scope.Properties)
+		for _, collection := range scope.Collections {
+			redactor.collections[strings.ToLower(collection)] = scoped
+		}
+		if scope.ODataType != "" {
+			redactor.types[strings.ToLower(scope.ODataType)] = scoped
+		}
+	}
+	return redactor
+}
+
+func names(properties []string) map[string]bool {
+	set := map[string]bool{}
+	for _, name := range properties {
+		set[strings.ToLower(name)] = true
+	}
+	return set
+}
+
+// scope the scoped properties of the objects u returns or receives: those of the collection u ends with, or
+// of the collection of the object u addresses by id
+func (r Redactor) scope(u *url.URL) map[string]bool {
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	last := len(segments) - 1
+	if scoped, found := r.collections[strings.ToLower(segments[last])]; found {
+		return scoped
+	}
+	if last > 0 {
+		return r.collections[strings.ToLower(segments[last-1])]
+	}
+	return nil
+}
+
+// redacts reports whether the property is redacted, everywhere or in the scope
+func (r Redactor) redacts(property string, scoped map[string]bool) bool {
+	property = strings.ToLower(property)
+	return r.properties[property] || scoped[property]
+}
+
+// Header a copy of header with the credential headers redacted, nil when header is empty
+func (r Redactor) Header(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	redacted := header.Clone()
+	for _, name := range Headers {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, Redacted)
+		}
+	}
+	return redacted
+}
+
+// Body body as JSON with the redacted properties replaced, those of a scope in the objects of the collection
+// of u and in the objects of its @odata.type. Bodies that are not JSON, such as the form posted to the token
+// endpoint, are returned as a JSON string with the redacted form values replaced.
+func (r Redactor) Body(u *url.URL, body []byte) json.RawMessage {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return marshal(r.form(string(body)))
+	}
+	return marshal(r.value(value, r.scope(u), false))
+}
+
+func (r Redactor) form(body string) string {
+	parts := strings.Split(body, "&")
+	for index, part := range parts {
+		name := strings.SplitN(part, "=", 2)[0]
+		if r.properties[strings.ToLower(name)] {
+			parts[index] = name + "=" + Redacted
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// URL the request URI of u with personal data replaced by a digest: path segments holding a user principal
+// name or mail address, the values of redacted query parameters, and the $filter and $search literals
+// compared with a property redacted in the collection of u or holding an address. Equal values get equal digests, so a redacted
+// URL still identifies the request.
+func (r Redactor) URL(u *url.URL) string {
+	segments := strings.Split(u.EscapedPath(), "/")
+	for index, segment := range segments {
+		if unescaped, err := url.PathUnescape(segment); err == nil && strings.Contains(unescaped, "@") {
+			segments[index] = digest(unescaped)
+		}
+	}
+	uri := strings.Join(segments, "/")
+
+	if u.RawQuery == "" {
+		return uri
+	}
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return uri + "?" + u.RawQuery
+	}
+	scoped := r.scope(u)
+	redacted := false
+	for name, values := range query {
+		for index, value := range values {
+			replaced := value
+			switch {
+			case r.redacts(name, scoped):
+				replaced = digest(value)
+			case name == "$filter" || name == "$search":
+				replaced = r.expression(value, scoped)
+			}
+			if replaced != value {
+				values[index] = replaced
+				redacted = true
+			}
+		}
+	}
+	if !redacted {
+		// kept as sent when nothing is redacted
+		return uri + "?" + u.RawQuery
+	}
+	return uri + "?" + query.Encode()
+}
+
+var (
+	// comparisons a property compared with a literal, e.g. mail eq 'x' or startswith(mail,'x')
+	comparisons = []*regexp.Regexp{
+		regexp.MustCompile(`(\w+)\s+(?:eq|ne|gt|ge|lt|le)\s+'((?:[^']|'')*)'`),
+		regexp.MustCompile(`(?:startswith|endswith|contains)\(\s*(\w+)\s*,\s*'((?:[^']|'')*)'\s*\)`),
+		regexp.MustCompile(`"(\w+):([^"]*)"`),
+	}
+	literal = regexp.MustCompile(`'((?:[^']|'')*)'`)
+)
+
+// expression the $filter or $search expression with the literals compared with redacted properties, and
+// those holding an address, replaced by their digest
+func (r Redactor) expression(expression string, scoped map[string]bool) string {
+	for _, comparison := range comparisons {
+		expression = comparison.ReplaceAllStringFunc(expression, func(match string) string {
+			parts := comparison.FindStringSubmatchIndex(match)
+			property, value := match[parts[2]:parts[3]], match[parts[4]:parts[5]]
+			if !r.redacts(property, scoped) {
+				return match
+			}
+			return match[:parts[4]] + digest(value) + match[parts[5]:]
+		})
+	}
+	return literal.ReplaceAllStringFunc(expression, func(match string) string {
+		if !strings.Contains(match, "@") {
+			return match
+		}
+		return "'" + digest(match[1:len(match)-1]) + "'"
+	})
+}
+
+// digest stands in for a redacted value in URLs
+func digest(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return Redacted + "-" + hex.EncodeToString(hash[:6])
+}
+
+// value value with the strings of redacted properties replaced, scoped the properties of the scope of the
+// objects. redact is set for the values nested in a redacted property, so lists and objects are redacted as
+// a whole.
+func (r Redactor) value(value interface{}, scoped map[string]bool, redact bool) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		if odataType, found := typed["@odata.type"].(string); found {
+			// mixed collections, e.g. the owners of a group, tell the type of each object
+			scoped = r.types[strings.ToLower(odataType)]
+		}
+		var keys []string
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			typed[key] = r.value(typed[key], scoped, redact || r.redacts(key, scoped))
+		}
+		return typed
+	case []interface{}:
+		for index := range typed {
+			typed[index] = r.value(typed[index], scoped, redact)
+		}
+		return typed
+	case string:
+		if redact {
+			return Redacted
+		}
+	}
+	return value
+}
+
+// marshal value as JSON without escaping HTML characters, which are common in URLs and form bodies
+func marshal(value interface{}) json.RawMessage {
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil
+	}
+	return bytes.TrimSuffix(data.Bytes(), []byte("\n"))
+}
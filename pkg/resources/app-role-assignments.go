@@ -0,0 +1,160 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"net/url"
+	"time"
+	"westpac.co.nz/msgraph/pkg/helpers"
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+// DefaultAppRoleID the role id used when assigning a principal to an application that publishes no app roles
+const DefaultAppRoleID = "00000000-0000-0000-0000-000000000000"
+
+// GraphAPIV1AppRoleAssignmentListResponse App Role Assignment List Response
+type GraphAPIV1AppRoleAssignmentListResponse struct {
+	AppRoleAssignments []GraphAPIV1AppRoleAssignmentResponse `json:"value"`
+}
+
+// GraphAPIV1AppRoleAssignmentResponse Graph API appRoleAssignment resource response
+type GraphAPIV1AppRoleAssignmentResponse struct {
+	ID                   string    `json:"id"`
+	AppRoleID            string    `json:"appRoleId"`
+	CreatedDateTime      time.Time `json:"createdDateTime"`
+	DeletedDateTime      time.Time `json:"deletedDateTime"`
+	PrincipalDisplayName string    `json:"principalDisplayName" pii:"true"`
+	PrincipalID          string    `json:"principalId"`
+	PrincipalType        string    `json:"principalType"`
+	ResourceDisplayName  string    `json:"resourceDisplayName"`
+	ResourceID           string    `json:"resourceId"`
+
+	AdditionalProperties msgraph.AdditionalProperties `json:"-"`
+}
+
+// UnmarshalJSON keeps the properties the struct does not declare in AdditionalProperties
+func (g *GraphAPIV1AppRoleAssignmentResponse) UnmarshalJSON(data []byte) error {
+	type assignment GraphAPIV1AppRoleAssignmentResponse
+	properties, err := msgraph.UnmarshalResource(data, (*assignment)(g))
+	g.AdditionalProperties = properties
+	return err
+}
+
+// MarshalJSON writes AdditionalProperties back next to the declared properties
+func (g GraphAPIV1AppRoleAssignmentResponse) MarshalJSON() ([]byte, error) {
+	type assignment GraphAPIV1AppRoleAssignmentResponse
+	return msgraph.MarshalResource(assignment(g), g.AdditionalProperties)
+}
+
+// GraphAPIV1AppRoleAssignmentRequest body used to grant an app role
+type GraphAPIV1AppRoleAssignmentRequest struct {
+	PrincipalID string `json:"principalId"`
+	ResourceID  string `json:"resourceId"`
+	AppRoleID   string `json:"appRoleId"`
+}
+
+func (g GraphAPIV1AppRoleAssignmentResponse) ToString() string {
+	return fmt.Sprintf("%s: %s (%s)", g.PrincipalDisplayName, g.ResourceDisplayName, g.AppRoleID)
+}
+
+// DefaultColumns the properties shown by the table output unless --fields is given
+func (g GraphAPIV1AppRoleAssignmentResponse) DefaultColumns() []string {
+	return []string{"principalDisplayName", "principalType", "resourceDisplayName", "appRoleId", "createdDateTime"}
+}
+
+// AppRoleAssignmentsResource app role assignments of a principal. PrincipalType is the
+// collection the principal lives in: 'users', 'groups' or 'servicePrincipals'
+type AppRoleAssignmentsResource struct {
+	PrincipalType string
+}
+
+func (g AppRoleAssignmentsResource) ConvertToResourceSlice(body []byte) []msgraph.Resource {
+	var appRoleAssignmentList GraphAPIV1AppRoleAssignmentListResponse
+	err := json.Unmarshal(body, &appRoleAssignmentList)
+	helpers.ErrorHandlerFatal("JSON unmarshalling of response body failed:", err)
+
+	log.Tracef("UNMASHALLED OBJECT: %+v", appRoleAssignmentList)
+
+	return g.toResourceArr(appRoleAssignmentList)
+}
+
+func (g AppRoleAssignmentsResource) toResourceArr(appRoleAssignmentList GraphAPIV1AppRoleAssignmentListResponse) []msgraph.Resource {
+	var resources = make([]msgraph.Resource, len(appRoleAssignmentList.AppRoleAssignments))
+	for index, value := range appRoleAssignmentList.AppRoleAssignments {
+		resources[index] = value
+	}
+	return resources
+}
+
+func (g AppRoleAssignmentsResource) ConvertToResource(body []byte) msgraph.Resource {
+	var appRoleAssignment GraphAPIV1AppRoleAssignmentResponse
+	err := json.Unmarshal(body, &appRoleAssignment)
+	helpers.ErrorHandlerFatal("JSON unmarshalling of response body failed:", err)
+
+	log.Tracef("UNMASHALLED OBJECT: %+v", appRoleAssignment)
+
+	return appRoleAssignment
+}
+
+// CreateRequestPath expects the principal id (or user principal name) as the first argument
+func (g AppRoleAssignmentsResource) CreateRequestPath(context cli.Context, args cli.Args) string {
+	return fmt.Sprintf("/%s/%s/appRoleAssignments", g.PrincipalType, args.Get(0))
+}
+
+func (g AppRoleAssignmentsResource) CreateQueryParams(context cli.Context, args cli.Args) url.Values {
+
+	resourceID := context.String("resource")
+	if resourceID != "" {
+		filter := new(msgraph.FilterCriteria)
+		criteria := filter.Equals("resourceId", resourceID)
+		return msgraph.CreateURLFilterParams(criteria)
+	}
+	return nil
+}
+
+// NewAssignment builds the request granting role on the resource service principal to principal.
+// The resource may be given by object id, appId or display name, the role by id, value or display name.
+func (g AppRoleAssignmentsResource) NewAssignment(ctx context.Context, b msgraph.BaseResource, principal string, resource string, role string) (GraphAPIV1AppRoleAssignmentRequest, error) {
+	var principalObject struct {
+		ID string `json:"id"`
+	}
+	path := fmt.Sprintf("/%s/%s", g.PrincipalType, principal)
+	body, err := b.Request(ctx, "GET", path, url.Values{"$select": []string{"id"}}, nil)
+	if err != nil {
+		return GraphAPIV1AppRoleAssignmentRequest{}, err
+	}
+	if err := json.Unmarshal(body, &principalObject); err != nil {
+		return GraphAPIV1AppRoleAssignmentRequest{}, fmt.Errorf("JSON unmarshalling of response body failed: %v", err)
+	}
+
+	servicePrincipal, found, err := ServicePrincipalsResource{}.Find(ctx, b, resource)
+	if err != nil {
+		return GraphAPIV1AppRoleAssignmentRequest{}, err
+	}
+	if !found {
+		return GraphAPIV1AppRoleAssignmentRequest{}, fmt.Errorf("No service principal found for resource '%s'", resource)
+	}
+
+	appRoleID := DefaultAppRoleID
+	if role != "" {
+		appRole, found := servicePrincipal.FindAppRole(role)
+		if !found {
+			return GraphAPIV1AppRoleAssignmentRequest{}, fmt.Errorf("Resource '%s' has no app role '%s'", servicePrincipal.DisplayName, role)
+		}
+		appRoleID = appRole.ID
+	}
+
+	return GraphAPIV1AppRoleAssignmentRequest{
+		PrincipalID: principalObject.ID,
+		ResourceID:  servicePrincipal.ID,
+		AppRoleID:   appRoleID,
+	}, nil
+}
+
+// CreateDeletePath path of a single assignment, expects the principal and the assignment id as arguments
+func (g AppRoleAssignmentsResource) CreateDeletePath(context cli.Context, args cli.Args) string {
+	return fmt.Sprintf("%s/%s", g.CreateRequestPath(context, args), args.Get(1))
+}
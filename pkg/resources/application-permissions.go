@@ -0,0 +1,141 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"westpac.co.nz/msgraph/pkg/helpers"
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+const (
+	// PermissionTypeApplication an app role, granted to the application itself
+	PermissionTypeApplication = "Application"
+	// PermissionTypeDelegated an oauth2 permission scope, exercised on behalf of a signed in user
+	PermissionTypeDelegated = "Delegated"
+)
+
+// ApplicationPermission a required permission of an application resolved to its human readable name
+type ApplicationPermission struct {
+	ResourceAppID       string `json:"resourceAppId"`
+	ResourceDisplayName string `json:"resourceDisplayName"`
+	PermissionID        string `json:"permissionId"`
+	Type                string `json:"type"`
+	Value               string `json:"value"`
+	DisplayName         string `json:"displayName"`
+	Description         string `json:"description"`
+	Granted             bool   `json:"granted"`
+}
+
+func (p ApplicationPermission) ToString() string {
+	granted := "not granted"
+	if p.Granted {
+		granted = "granted"
+	}
+	return fmt.Sprintf("%s: %s (%s, %s)", p.ResourceDisplayName, p.Value, p.Type, granted)
+}
+
+// DefaultColumns the properties shown by the table output unless --fields is given
+func (p ApplicationPermission) DefaultColumns() []string {
+	return []string{"resourceDisplayName", "type", "value", "displayName", "granted"}
+}
+
+// Find looks up a single application by object id, appId or display name
+func (g ApplicationsResource) Find(ctx context.Context, b msgraph.BaseResource, key string) (GraphAPIV1ApplicationResponse, bool, error) {
+	filter := new(msgraph.FilterCriteria)
+	criteria := filter.Equals("displayName", key)
+	if helpers.IsGUID(key) {
+		criteria = filter.LogicOr(filter.Equals("id", key), filter.Equals("appId", key))
+	}
+
+	applications, err := b.ListPath(ctx, g, "/applications", msgraph.CreateURLFilterParams(criteria))
+	if err != nil || len(applications) == 0 {
+		return GraphAPIV1ApplicationResponse{}, false, err
+	}
+	if len(applications) > 1 {
+		log.Warnf("Multiple applications match '%s', using the first", key)
+	}
+	return applications[0].(GraphAPIV1ApplicationResponse), true, nil
+}
+
+// Permissions resolves the requiredResourceAccess of the application into named permissions by looking up
+// the app roles and oauth2 permission scopes of each resource service principal. A permission is marked
+// granted when the application's own service principal holds the app role or an admin consented delegated grant.
+func (g ApplicationsResource) Permissions(ctx context.Context, b msgraph.BaseResource, application GraphAPIV1ApplicationResponse) ([]msgraph.Resource, error) {
+	servicePrincipals := ServicePrincipalsResource{}
+
+	grantedRoles := map[string]bool{}
+	grantedScopes := map[string]bool{}
+	client, found, err := servicePrincipals.Find(ctx, b, application.AppID)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		assignments := AppRoleAssignmentsResource{PrincipalType: "servicePrincipals"}
+		path := fmt.Sprintf("/servicePrincipals/%s/appRoleAssignments", client.ID)
+		resources, err := b.ListPath(ctx, assignments, path, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range resources {
+			assignment := resource.(GraphAPIV1AppRoleAssignmentResponse)
+			grantedRoles[assignment.ResourceID+"/"+assignment.AppRoleID] = true
+		}
+
+		grants, err := OAuth2PermissionGrantsResource{}.ListForClient(ctx, b, client.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, grant := range grants {
+			// a user consenting for themselves grants nothing to the other users
+			if grant.ConsentType != ConsentTypeAllPrincipals {
+				continue
+			}
+			for _, scope := range grant.Scopes() {
+				grantedScopes[grant.ResourceID+"/"+scope] = true
+			}
+		}
+	}
+
+	var permissions []msgraph.Resource
+	for _, required := range application.RequiredResourceAccess {
+		resourceSP, found, err := servicePrincipals.Find(ctx, b, required.ResourceAppID)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			log.Warnf("No service principal found for resource application %s", required.ResourceAppID)
+		}
+
+		for _, access := range required.ResourceAccess {
+			permission := ApplicationPermission{
+				ResourceAppID:       required.ResourceAppID,
+				ResourceDisplayName: resourceSP.DisplayName,
+				PermissionID:        access.ID,
+				Value:               access.ID,
+			}
+			switch access.Type {
+			case "Role":
+				permission.Type = PermissionTypeApplication
+				if appRole, found := resourceSP.FindAppRole(access.ID); found {
+					permission.Value = appRole.Value
+					permission.DisplayName = appRole.DisplayName
+					permission.Description = appRole.Description
+				}
+				permission.Granted = grantedRoles[resourceSP.ID+"/"+access.ID]
+			case "Scope":
+				permission.Type = PermissionTypeDelegated
+				if scope, found := resourceSP.FindPermissionScope(access.ID); found {
+					permission.Value = scope.Value
+					permission.DisplayName = scope.AdminConsentDisplayName
+					permission.Description = scope.AdminConsentDescription
+				}
+				permission.Granted = grantedScopes[resourceSP.ID+"/"+permission.Value]
+			default:
+				permission.Type = access.Type
+			}
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions, nil
+}
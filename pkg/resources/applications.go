@@ -32,12 +32,55 @@ type GraphAPIV1ApplicationResponse struct {
 	SignInAudience            string    `json:"signInAudience"`
 	Tags                      []string  `json:"tags"`
 	TokenEncryptionKeyID      string    `json:"tokenEncryptionKeyId"`
+
+	RequiredResourceAccess []GraphAPIV1RequiredResourceAccess `json:"requiredResourceAccess"`
+
+	AdditionalProperties msgraph.AdditionalProperties `json:"-"`
+}
+
+// GraphAPIV1RequiredResourceAccess permissions an application requires on a resource application
+type GraphAPIV1RequiredResourceAccess struct {
+	ResourceAppID  string                     `json:"resourceAppId"`
+	ResourceAccess []GraphAPIV1ResourceAccess `json:"resourceAccess"`
+}
+
+// GraphAPIV1ResourceAccess a single required permission, type is either 'Scope' (delegated) or 'Role' (application)
+type GraphAPIV1ResourceAccess struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// UnmarshalJSON keeps the properties the struct does not declare in AdditionalProperties
+func (g *GraphAPIV1ApplicationResponse) UnmarshalJSON(data []byte) error {
+	type application GraphAPIV1ApplicationResponse
+	properties, err := msgraph.UnmarshalResource(data, (*application)(g))
+	g.AdditionalProperties = properties
+	return err
+}
+
+// MarshalJSON writes AdditionalProperties back next to the declared properties
+func (g GraphAPIV1ApplicationResponse) MarshalJSON() ([]byte, error) {
+	type application GraphAPIV1ApplicationResponse
+	return msgraph.MarshalResource(application(g), g.AdditionalProperties)
 }
 
 func (g GraphAPIV1ApplicationResponse) ToString() string {
 	return g.DisplayName
 }
 
+func init() {
+	msgraph.Register(msgraph.Registration{
+		Name:           "applications",
+		ODataType:      "#microsoft.graph.application",
+		Aliases:        []string{"a"},
+		ListArgument:   "application name start",
+		API:            ApplicationsResource{},
+		DefaultColumns: []string{"id", "appId", "displayName", "signInAudience", "createdDateTime"},
+		Filterable:     []string{"appId", "signInAudience", "publisherDomain"},
+		Verbs:          []msgraph.Verb{msgraph.VerbList, msgraph.VerbGet, msgraph.VerbDelete},
+	})
+}
+
 // ApplicationsResource ApplicationsResource
 type ApplicationsResource struct{}
 
@@ -59,8 +102,18 @@ func (g ApplicationsResource) toResourceArr(applicationList GraphAPIV1Applicatio
 	return resources
 }
 
+func (g ApplicationsResource) ConvertToResource(body []byte) msgraph.Resource {
+	var application GraphAPIV1ApplicationResponse
+	err := json.Unmarshal(body, &application)
+	helpers.ErrorHandlerFatal("JSON unmarshalling of response body failed:", err)
+
+	log.Tracef("UNMASHALLED OBJECT: %+v", application)
+
+	return application
+}
+
 func (g ApplicationsResource) CreateRequestPath(context cli.Context, args cli.Args) string {
-	return "/v1.0/applications"
+	return "/applications"
 }
 
 func (g ApplicationsResource) CreateQueryParams(context cli.Context, args cli.Args) url.Values {
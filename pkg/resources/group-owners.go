@@ -0,0 +1,41 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+// GroupOwners a group with the display names of its owners
+type GroupOwners struct {
+	ID          string   `json:"id"`
+	DisplayName string   `json:"displayName"`
+	OwnerCount  int      `json:"ownerCount"`
+	Owners      []string `json:"owners"`
+}
+
+func (g GroupOwners) ToString() string {
+	return fmt.Sprintf("%s: %d owners", g.DisplayName, g.OwnerCount)
+}
+
+// DefaultColumns the properties shown by the table output unless --fields is given
+func (g GroupOwners) DefaultColumns() []string {
+	return []string{"displayName", "ownerCount", "owners"}
+}
+
+// Owners looks up the owners of the group, users or service principals
+func (g GroupsResource) Owners(ctx context.Context, b msgraph.BaseResource, group GraphAPIV1GroupResponse) (GroupOwners, error) {
+	path := fmt.Sprintf("/groups/%s/owners", group.ID)
+	owners, err := b.ListPath(ctx, UsersResource{}, path, url.Values{"$select": []string{"id,displayName"}})
+	if err != nil {
+		return GroupOwners{}, err
+	}
+
+	result := GroupOwners{ID: group.ID, DisplayName: group.DisplayName, OwnerCount: len(owners), Owners: []string{}}
+	for _, owner := range owners {
+		result.Owners = append(result.Owners, owner.(GraphAPIV1UserResponse).DisplayName)
+	}
+	return result, nil
+}
@@ -23,9 +23,13 @@ type GraphAPIV1GroupResponse struct {
 	CreatedDateTime       time.Time `json:"createdDateTime"`
 	Description           string    `json:"description"`
 	DisplayName           string    `json:"displayName"`
+	ExpirationDateTime    time.Time `json:"expirationDateTime"`
+	GroupTypes            []string  `json:"groupTypes"`
+	IsAssignableToRole    bool      `json:"isAssignableToRole"`
 	Mail                  string    `json:"mail"`
 	MailEnabled           bool      `json:"mailEnabled"`
 	MailNickName          string    `json:"mailNickname"`
+	MembershipRule        string    `json:"membershipRule"`
 	LastSyncDateTime      time.Time `json:"onPremisesLastSyncDateTime"`
 	SecurityIdentifier    string    `json:"onPremisesSecurityIdentifier"`
 	SyncEnabled           bool      `json:"onPremisesSyncEnabled"`
@@ -33,12 +37,41 @@ type GraphAPIV1GroupResponse struct {
 	RenewedDateTime       time.Time `json:"renewedDateTime"`
 	SecurityEnabled       bool      `json:"securityEnabled"`
 	Visibility            string    `json:"visibility"`
+
+	AdditionalProperties msgraph.AdditionalProperties `json:"-"`
+}
+
+// UnmarshalJSON keeps the properties the struct does not declare in AdditionalProperties
+func (g *GraphAPIV1GroupResponse) UnmarshalJSON(data []byte) error {
+	type group GraphAPIV1GroupResponse
+	properties, err := msgraph.UnmarshalResource(data, (*group)(g))
+	g.AdditionalProperties = properties
+	return err
+}
+
+// MarshalJSON writes AdditionalProperties back next to the declared properties
+func (g GraphAPIV1GroupResponse) MarshalJSON() ([]byte, error) {
+	type group GraphAPIV1GroupResponse
+	return msgraph.MarshalResource(group(g), g.AdditionalProperties)
 }
 
 func (g GraphAPIV1GroupResponse) ToString() string {
 	return g.DisplayName
 }
 
+func init() {
+	msgraph.Register(msgraph.Registration{
+		Name:           "groups",
+		ODataType:      "#microsoft.graph.group",
+		Aliases:        []string{"g"},
+		ListArgument:   "group name start",
+		API:            GroupsResource{},
+		DefaultColumns: []string{"id", "displayName", "mail", "securityEnabled", "mailEnabled"},
+		Filterable:     []string{"mail", "mailNickname", "classification", "visibility"},
+		Verbs:          []msgraph.Verb{msgraph.VerbList, msgraph.VerbGet, msgraph.VerbDelete},
+	})
+}
+
 // GroupsResource GroupsResource
 type GroupsResource struct{}
 
@@ -60,8 +93,18 @@ func (g GroupsResource) toResourceArr(groupList GraphAPIV1GroupListResponse) []m
 	return resources
 }
 
+func (g GroupsResource) ConvertToResource(body []byte) msgraph.Resource {
+	var group GraphAPIV1GroupResponse
+	err := json.Unmarshal(body, &group)
+	helpers.ErrorHandlerFatal("JSON unmarshalling of response body failed:", err)
+
+	log.Tracef("UNMASHALLED OBJECT: %+v", group)
+
+	return group
+}
+
 func (g GroupsResource) CreateRequestPath(context cli.Context, args cli.Args) string {
-	return "/v1.0/groups"
+	return "/groups"
 }
 
 func (g GroupsResource) CreateQueryParams(context cli.Context, args cli.Args) url.Values {
@@ -78,3 +121,17 @@ func (g GroupsResource) CreateQueryParams(context cli.Context, args cli.Args) ur
 	}
 	return nil
 }
+
+// MyGroupsResource the groups the signed in user is a member of, requires delegated authentication
+type MyGroupsResource struct {
+	GroupsResource
+}
+
+func (g MyGroupsResource) CreateRequestPath(context cli.Context, args cli.Args) string {
+	return "/me/memberOf/microsoft.graph.group"
+}
+
+// CreateQueryParams filtering memberOf needs advanced queries, the full membership is returned
+func (g MyGroupsResource) CreateQueryParams(context cli.Context, args cli.Args) url.Values {
+	return nil
+}
@@ -0,0 +1,306 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"westpac.co.nz/msgraph/pkg/helpers"
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+const (
+	// ConsentTypeAllPrincipals consent granted by an administrator on behalf of all users
+	ConsentTypeAllPrincipals = "AllPrincipals"
+	// ConsentTypePrincipal consent granted by a single user for themselves
+	ConsentTypePrincipal = "Principal"
+)
+
+// DefaultRiskScopes delegated scopes flagged as high risk by the grants audit unless overridden
+var DefaultRiskScopes = []string{
+	"Application.ReadWrite.All",
+	"Directory.AccessAsUser.All",
+	"Directory.ReadWrite.All",
+	"Files.ReadWrite.All",
+	"Group.ReadWrite.All",
+	"Mail.ReadWrite",
+	"Mail.Send",
+	"MailboxSettings.ReadWrite",
+	"offline_access",
+	"RoleManagement.ReadWrite.Directory",
+	"Sites.FullControl.All",
+	"Sites.ReadWrite.All",
+	"User.ReadWrite.All",
+}
+
+// GraphAPIV1OAuth2PermissionGrantListResponse OAuth2 Permission Grant List Response
+type GraphAPIV1OAuth2PermissionGrantListResponse struct {
+	OAuth2PermissionGrants []GraphAPIV1OAuth2PermissionGrantResponse `json:"value"`
+}
+
+// GraphAPIV1OAuth2PermissionGrantResponse Graph API oauth2PermissionGrants resource response
+type GraphAPIV1OAuth2PermissionGrantResponse struct {
+	ID          string `json:"id"`
+	ClientID    string `json:"clientId"`
+	ConsentType string `json:"consentType"`
+	PrincipalID string `json:"principalId"`
+	ResourceID  string `json:"resourceId"`
+	Scope       string `json:"scope"`
+
+	AdditionalProperties msgraph.AdditionalProperties `json:"-"`
+}
+
+// UnmarshalJSON keeps the properties the struct does not declare in AdditionalProperties
+func (g *GraphAPIV1OAuth2PermissionGrantResponse) UnmarshalJSON(data []byte) error {
+	type grant GraphAPIV1OAuth2PermissionGrantResponse
+	properties, err := msgraph.UnmarshalResource(data, (*grant)(g))
+	g.AdditionalProperties = properties
+	return err
+}
+
+// MarshalJSON writes AdditionalProperties back next to the declared properties
+func (g GraphAPIV1OAuth2PermissionGrantResponse) MarshalJSON() ([]byte, error) {
+	type grant GraphAPIV1OAuth2PermissionGrantResponse
+	return msgraph.MarshalResource(grant(g), g.AdditionalProperties)
+}
+
+func (g GraphAPIV1OAuth2PermissionGrantResponse) ToString() string {
+	return fmt.Sprintf("%s: %s", g.ID, g.Scope)
+}
+
+// Scopes the granted delegated scopes
+func (g GraphAPIV1OAuth2PermissionGrantResponse) Scopes() []string {
+	return strings.Fields(g.Scope)
+}
+
+// OAuth2PermissionGrantAudit a delegated permission grant with its principals resolved and high risk scopes flagged
+type OAuth2PermissionGrantAudit struct {
+	ID                  string   `json:"id"`
+	ClientID            string   `json:"clientId"`
+	ClientDisplayName   string   `json:"clientDisplayName"`
+	ResourceID          string   `json:"resourceId"`
+	ResourceDisplayName string   `json:"resourceDisplayName"`
+	Consent             string   `json:"consent"`
+	PrincipalID         string   `json:"principalId"`
+	PrincipalName       string   `json:"principalName"`
+	Scopes              []string `json:"scopes"`
+	RiskScopes          []string `json:"riskScopes"`
+	HighRisk            bool     `json:"highRisk"`
+}
+
+func (g OAuth2PermissionGrantAudit) ToString() string {
+	grantedBy := "admin consent"
+	if g.Consent == "user" {
+		grantedBy = "user consent by " + g.PrincipalName
+	}
+	risk := ""
+	if g.HighRisk {
+		risk = fmt.Sprintf(" HIGH RISK: %s", strings.Join(g.RiskScopes, " "))
+	}
+	return fmt.Sprintf("%s -> %s (%s): %s%s", g.ClientDisplayName, g.ResourceDisplayName, grantedBy, strings.Join(g.Scopes, " "), risk)
+}
+
+// DefaultColumns the properties shown by the table output unless --fields is given
+func (g OAuth2PermissionGrantAudit) DefaultColumns() []string {
+	return []string{"clientDisplayName", "resourceDisplayName", "consent", "principalName", "riskScopes", "highRisk"}
+}
+
+func init() {
+	msgraph.Register(msgraph.Registration{
+		Name:           "oauth2PermissionGrants",
+		ODataType:      "#microsoft.graph.oAuth2PermissionGrant",
+		Aliases:        []string{"grants", "o"},
+		Plural:         "delegated permission grants",
+		Description:    "Actions for the delegated permission grants (consents) of the tenant",
+		ListArgument:   "client service principal object id",
+		API:            OAuth2PermissionGrantsResource{},
+		DefaultColumns: []string{"id", "clientId", "consentType", "principalId", "resourceId", "scope"},
+		Filterable:     []string{"clientId", "consentType", "principalId", "resourceId"},
+		Verbs:          []msgraph.Verb{msgraph.VerbList, msgraph.VerbGet},
+	})
+}
+
+// OAuth2PermissionGrantsResource OAuth2PermissionGrantsResource
+type OAuth2PermissionGrantsResource struct{}
+
+func (g OAuth2PermissionGrantsResource) ConvertToResourceSlice(body []byte) []msgraph.Resource {
+	var grantList GraphAPIV1OAuth2PermissionGrantListResponse
+	err := json.Unmarshal(body, &grantList)
+	helpers.ErrorHandlerFatal("JSON unmarshalling of response body failed:", err)
+
+	log.Tracef("UNMASHALLED OBJECT: %+v", grantList)
+
+	return g.toResourceArr(grantList)
+}
+
+func (g OAuth2PermissionGrantsResource) toResourceArr(grantList GraphAPIV1OAuth2PermissionGrantListResponse) []msgraph.Resource {
+	var resources = make([]msgraph.Resource, len(grantList.OAuth2PermissionGrants))
+	for index, value := range grantList.OAuth2PermissionGrants {
+		resources[index] = value
+	}
+	return resources
+}
+
+func (g OAuth2PermissionGrantsResource) ConvertToResource(body []byte) msgraph.Resource {
+	var grant GraphAPIV1OAuth2PermissionGrantResponse
+	err := json.Unmarshal(body, &grant)
+	helpers.ErrorHandlerFatal("JSON unmarshalling of response body failed:", err)
+
+	log.Tracef("UNMASHALLED OBJECT: %+v", grant)
+
+	return grant
+}
+
+func (g OAuth2PermissionGrantsResource) CreateRequestPath(context cli.Context, args cli.Args) string {
+	return "/oauth2PermissionGrants"
+}
+
+// CreateQueryParams optionally filters on the client service principal object id given as first argument
+func (g OAuth2PermissionGrantsResource) CreateQueryParams(context cli.Context, args cli.Args) url.Values {
+
+	clientID := ""
+	if args.Len() > 0 {
+		clientID = args.Get(0)
+	}
+
+	if clientID != "" {
+		filter := new(msgraph.FilterCriteria)
+		criteria := filter.Equals("clientId", clientID)
+		return msgraph.CreateURLFilterParams(criteria)
+	}
+	return nil
+}
+
+// ListForClient the grants of the client service principal with object id clientID
+func (g OAuth2PermissionGrantsResource) ListForClient(ctx context.Context, b msgraph.BaseResource, clientID string) ([]GraphAPIV1OAuth2PermissionGrantResponse, error) {
+	filter := new(msgraph.FilterCriteria)
+	params := msgraph.CreateURLFilterParams(filter.Equals("clientId", clientID))
+
+	resources, err := b.ListPath(ctx, g, g.CreateRequestPath(cli.Context{}, nil), params)
+	if err != nil {
+		return nil, err
+	}
+	var grants []GraphAPIV1OAuth2PermissionGrantResponse
+	for _, resource := range resources {
+		grants = append(grants, resource.(GraphAPIV1OAuth2PermissionGrantResponse))
+	}
+	return grants, nil
+}
+
+// Audit resolves the client, resource and consenting user of each grant and flags the scopes in riskScopes
+func (g OAuth2PermissionGrantsResource) Audit(ctx context.Context, b msgraph.BaseResource, grants []msgraph.Resource, riskScopes []string) ([]msgraph.Resource, error) {
+	names := directoryObjectNames{ctx: ctx, base: b, names: map[string]string{}}
+
+	var audits = make([]msgraph.Resource, len(grants))
+	for index, resource := range grants {
+		grant := resource.(GraphAPIV1OAuth2PermissionGrantResponse)
+
+		audit := OAuth2PermissionGrantAudit{
+			ID:                  grant.ID,
+			ClientID:            grant.ClientID,
+			ClientDisplayName:   names.lookup("servicePrincipals", grant.ClientID, "displayName"),
+			ResourceID:          grant.ResourceID,
+			ResourceDisplayName: names.lookup("servicePrincipals", grant.ResourceID, "displayName"),
+			Consent:             "admin",
+			PrincipalID:         grant.PrincipalID,
+			Scopes:              grant.Scopes(),
+		}
+		if grant.ConsentType == ConsentTypePrincipal {
+			audit.Consent = "user"
+			audit.PrincipalName = names.lookup("users", grant.PrincipalID, "userPrincipalName")
+		}
+		for _, scope := range audit.Scopes {
+			for _, riskScope := range riskScopes {
+				if strings.EqualFold(scope, riskScope) {
+					audit.RiskScopes = append(audit.RiskScopes, scope)
+				}
+			}
+		}
+		audit.HighRisk = len(audit.RiskScopes) > 0
+
+		audits[index] = audit
+	}
+	if names.err != nil {
+		return nil, names.err
+	}
+	return audits, nil
+}
+
+// RevokePath path of the grant with id grantID
+func (g OAuth2PermissionGrantsResource) RevokePath(grantID string) string {
+	return fmt.Sprintf("%s/%s", g.CreateRequestPath(cli.Context{}, nil), grantID)
+}
+
+// Revoke deletes the grant, or when scopes are given removes only those scopes from it.
+// The grant is deleted once no scopes remain.
+func (g OAuth2PermissionGrantsResource) Revoke(ctx context.Context, b msgraph.BaseResource, grantID string, scopes []string) error {
+	path := g.RevokePath(grantID)
+	if len(scopes) == 0 {
+		return b.Delete(ctx, path)
+	}
+
+	body, err := b.Request(ctx, "GET", path, nil, nil)
+	if err != nil {
+		return err
+	}
+	grant := g.ConvertToResource(body).(GraphAPIV1OAuth2PermissionGrantResponse)
+
+	var remaining []string
+	for _, granted := range grant.Scopes() {
+		revoked := false
+		for _, scope := range scopes {
+			revoked = revoked || strings.EqualFold(granted, scope)
+		}
+		if !revoked {
+			remaining = append(remaining, granted)
+		}
+	}
+
+	if len(remaining) == 0 {
+		return b.Delete(ctx, path)
+	}
+	return b.Update(ctx, path, map[string]string{"scope": strings.Join(remaining, " ")})
+}
+
+// directoryObjectNames caches the display names of directory objects looked up by id. Objects that no longer
+// exist have an empty name. The first failed lookup is kept in err and ends further lookups.
+type directoryObjectNames struct {
+	ctx   context.Context
+	base  msgraph.BaseResource
+	names map[string]string
+	err   error
+}
+
+func (d *directoryObjectNames) lookup(collection string, id string, property string) string {
+	if id == "" || d.err != nil {
+		return ""
+	}
+	if name, found := d.names[id]; found {
+		return name
+	}
+
+	var object map[string]interface{}
+	path := fmt.Sprintf("/%s/%s", collection, id)
+	body, err := d.base.Request(d.ctx, "GET", path, url.Values{"$select": []string{property}}, nil)
+	if graphErr, ok := err.(*msgraph.GraphAPIError); ok && graphErr.StatusCode == http.StatusNotFound {
+		// deleted since the grant was given, reported without a name
+		d.names[id] = ""
+		return ""
+	}
+	if err != nil {
+		d.err = err
+		return ""
+	}
+	if err := json.Unmarshal(body, &object); err != nil {
+		d.err = fmt.Errorf("JSON unmarshalling of response body failed: %v", err)
+		return ""
+	}
+
+	name, _ := object[property].(string)
+	d.names[id] = name
+	return name
+}
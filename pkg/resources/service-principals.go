@@ -0,0 +1,178 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"net/url"
+	"time"
+	"westpac.co.nz/msgraph/pkg/helpers"
+	"westpac.co.nz/msgraph/pkg/msgraph"
+)
+
+// GraphAPIV1ServicePrincipalListResponse Service Principal List Response
+type GraphAPIV1ServicePrincipalListResponse struct {
+	ServicePrincipals []GraphAPIV1ServicePrincipalResponse `json:"value"`
+}
+
+// GraphAPIV1ServicePrincipalResponse Graph API servicePrincipals resource response
+type GraphAPIV1ServicePrincipalResponse struct {
+	ID                        string    `json:"id"`
+	DisplayName               string    `json:"displayName"`
+	DeletedDataTime           time.Time `json:"deletedDateTime"`
+	AccountEnabled            bool      `json:"accountEnabled"`
+	AppDisplayName            string    `json:"appDisplayName"`
+	AppID                     string    `json:"appId"`
+	AppOwnerOrganizationID    string    `json:"appOwnerOrganizationId"`
+	AppRoleAssignmentRequired bool      `json:"appRoleAssignmentRequired"`
+	ServicePrincipalNames     []string  `json:"servicePrincipalNames"`
+	ServicePrincipalType      string    `json:"servicePrincipalType"`
+	SignInAudience            string    `json:"signInAudience"`
+	Tags                      []string  `json:"tags"`
+
+	AppRoles               []GraphAPIV1AppRole         `json:"appRoles"`
+	OAuth2PermissionScopes []GraphAPIV1PermissionScope `json:"oauth2PermissionScopes"`
+
+	AdditionalProperties msgraph.AdditionalProperties `json:"-"`
+}
+
+// GraphAPIV1AppRole an application permission (or assignable role) published by a service principal
+type GraphAPIV1AppRole struct {
+	ID                 string   `json:"id"`
+	AllowedMemberTypes []string `json:"allowedMemberTypes"`
+	Description        string   `json:"description"`
+	DisplayName        string   `json:"displayName"`
+	IsEnabled          bool     `json:"isEnabled"`
+	Value              string   `json:"value"`
+}
+
+// GraphAPIV1PermissionScope a delegated permission published by a service principal
+type GraphAPIV1PermissionScope struct {
+	ID                      string `json:"id"`
+	AdminConsentDescription string `json:"adminConsentDescription"`
+	AdminConsentDisplayName string `json:"adminConsentDisplayName"`
+	IsEnabled               bool   `json:"isEnabled"`
+	Type                    string `json:"type"`
+	Value                   string `json:"value"`
+}
+
+// UnmarshalJSON keeps the properties the struct does not declare in AdditionalProperties
+func (g *GraphAPIV1ServicePrincipalResponse) UnmarshalJSON(data []byte) error {
+	type servicePrincipal GraphAPIV1ServicePrincipalResponse
+	properties, err := msgraph.UnmarshalResource(data, (*servicePrincipal)(g))
+	g.AdditionalProperties = properties
+	return err
+}
+
+// MarshalJSON writes AdditionalProperties back next to the declared properties
+func (g GraphAPIV1ServicePrincipalResponse) MarshalJSON() ([]byte, error) {
+	type servicePrincipal GraphAPIV1ServicePrincipalResponse
+	return msgraph.MarshalResource(servicePrincipal(g), g.AdditionalProperties)
+}
+
+func (g GraphAPIV1ServicePrincipalResponse) ToString() string {
+	return g.DisplayName
+}
+
+// FindAppRole looks up an app role by id, value or display name
+func (g GraphAPIV1ServicePrincipalResponse) FindAppRole(role string) (GraphAPIV1AppRole, bool) {
+	for _, appRole := range g.AppRoles {
+		if appRole.ID == role || appRole.Value == role || appRole.DisplayName == role {
+			return appRole, true
+		}
+	}
+	return GraphAPIV1AppRole{}, false
+}
+
+// FindPermissionScope looks up a delegated permission by id or value
+func (g GraphAPIV1ServicePrincipalResponse) FindPermissionScope(scope string) (GraphAPIV1PermissionScope, bool) {
+	for _, permissionScope := range g.OAuth2PermissionScopes {
+		if permissionScope.ID == scope || permissionScope.Value == scope {
+			return permissionScope, true
+		}
+	}
+	return GraphAPIV1PermissionScope{}, false
+}
+
+func init() {
+	msgraph.Register(msgraph.Registration{
+		Name:           "servicePrincipals",
+		ODataType:      "#microsoft.graph.servicePrincipal",
+		Aliases:        []string{"sp"},
+		Plural:         "service principals",
+		ListArgument:   "service principal name start",
+		API:            ServicePrincipalsResource{},
+		DefaultColumns: []string{"id", "appId", "displayName", "servicePrincipalType", "accountEnabled"},
+		Filterable:     []string{"appId", "servicePrincipalType", "appOwnerOrganizationId"},
+		Verbs:          []msgraph.Verb{msgraph.VerbList, msgraph.VerbGet},
+	})
+}
+
+// ServicePrincipalsResource ServicePrincipalsResource
+type ServicePrincipalsResource struct{}
+
+func (g ServicePrincipalsResource) ConvertToResourceSlice(body []byte) []msgraph.Resource {
+	var servicePrincipalList GraphAPIV1ServicePrincipalListResponse
+	err := json.Unmarshal(body, &servicePrincipalList)
+	helpers.ErrorHandlerFatal("JSON unmarshalling of response body failed:", err)
+
+	log.Tracef("UNMASHALLED OBJECT: %+v", servicePrincipalList)
+
+	return g.toResourceArr(servicePrincipalList)
+}
+
+func (g ServicePrincipalsResource) toResourceArr(servicePrincipalList GraphAPIV1ServicePrincipalListResponse) []msgraph.Resource {
+	var resources = make([]msgraph.Resource, len(servicePrincipalList.ServicePrincipals))
+	for index, value := range servicePrincipalList.ServicePrincipals {
+		resources[index] = value
+	}
+	return resources
+}
+
+func (g ServicePrincipalsResource) ConvertToResource(body []byte) msgraph.Resource {
+	var servicePrincipal GraphAPIV1ServicePrincipalResponse
+	err := json.Unmarshal(body, &servicePrincipal)
+	helpers.ErrorHandlerFatal("JSON unmarshalling of response body failed:", err)
+
+	log.Tracef("UNMASHALLED OBJECT: %+v", servicePrincipal)
+
+	return servicePrincipal
+}
+
+func (g ServicePrincipalsResource) CreateRequestPath(context cli.Context, args cli.Args) string {
+	return "/servicePrincipals"
+}
+
+func (g ServicePrincipalsResource) CreateQueryParams(context cli.Context, args cli.Args) url.Values {
+
+	startWith := ""
+	if args.Len() > 0 {
+		startWith = args.Get(0)
+	}
+
+	if startWith != "" {
+		filter := new(msgraph.FilterCriteria)
+		criteria := filter.StartWith("displayName", startWith)
+		return msgraph.CreateURLFilterParams(criteria)
+	}
+	return nil
+}
+
+// Find looks up a single service principal by object id, appId or display name
+func (g ServicePrincipalsResource) Find(ctx context.Context, b msgraph.BaseResource, key string) (GraphAPIV1ServicePrincipalResponse, bool, error) {
+	filter := new(msgraph.FilterCriteria)
+	criteria := filter.LogicOr(filter.Equals("appId", key), filter.Equals("displayName", key))
+	if helpers.IsGUID(key) {
+		criteria = filter.LogicOr(filter.Equals("id", key), filter.Equals("appId", key))
+	}
+
+	servicePrincipals, err := b.ListPath(ctx, g, g.CreateRequestPath(cli.Context{}, nil), msgraph.CreateURLFilterParams(criteria))
+	if err != nil || len(servicePrincipals) == 0 {
+		return GraphAPIV1ServicePrincipalResponse{}, false, err
+	}
+	if len(servicePrincipals) > 1 {
+		log.Warnf("Multiple service principals match '%s', using the first", key)
+	}
+	return servicePrincipals[0].(GraphAPIV1ServicePrincipalResponse), true, nil
+}
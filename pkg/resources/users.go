@@ -5,6 +5,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 	"net/url"
+	"time"
 	"westpac.co.nz/msgraph/pkg/helpers"
 	"westpac.co.nz/msgraph/pkg/msgraph"
 )
@@ -17,22 +18,71 @@ type GraphAPIV1UserListResponse struct {
 // GraphAPIV1UserResponse Graph API users resource response
 type GraphAPIV1UserResponse struct {
 	ID                string   `json:"id"`
-	DisplayName       string   `json:"displayName"`
-	Mail              string   `json:"mail"`
-	BusinessPhones    []string `json:"businessPhones"`
-	GivenName         string   `json:"givenName"`
+	DisplayName       string   `json:"displayName" pii:"true"`
+	Mail              string   `json:"mail" pii:"true"`
+	BusinessPhones    []string `json:"businessPhones" pii:"true"`
+	GivenName         string   `json:"givenName" pii:"true"`
 	JobTitle          string   `json:"jobTitle"`
-	MobilePhone       string   `json:"mobilePhone"`
+	MobilePhone       string   `json:"mobilePhone" pii:"true"`
 	OfficeLocation    string   `json:"officeLocation"`
 	PreferredLanguage string   `json:"preferredLanguage"`
-	Surname           string   `json:"surname"`
-	UserPrincipalName string   `json:"userPrincipalName"`
+	Surname           string   `json:"surname" pii:"true"`
+	UserPrincipalName string   `json:"userPrincipalName" pii:"true"`
+
+	// only returned when selected
+	AccountEnabled  *bool      `json:"accountEnabled,omitempty"`
+	CompanyName     string     `json:"companyName,omitempty"`
+	CreatedDateTime *time.Time `json:"createdDateTime,omitempty"`
+	Department      string     `json:"department,omitempty"`
+	EmployeeType    string     `json:"employeeType,omitempty"`
+	UsageLocation   string     `json:"usageLocation,omitempty"`
+	UserType        string     `json:"userType,omitempty"`
+
+	// beta only, and only returned when selected
+	SignInActivity *GraphAPISignInActivity `json:"signInActivity,omitempty"`
+
+	AdditionalProperties msgraph.AdditionalProperties `json:"-"`
+}
+
+// GraphAPISignInActivity the last interactive and non interactive sign ins of a user
+type GraphAPISignInActivity struct {
+	LastSignInDateTime                time.Time `json:"lastSignInDateTime"`
+	LastSignInRequestID               string    `json:"lastSignInRequestId"`
+	LastNonInteractiveSignInDateTime  time.Time `json:"lastNonInteractiveSignInDateTime"`
+	LastNonInteractiveSignInRequestID string    `json:"lastNonInteractiveSignInRequestId"`
+}
+
+// UnmarshalJSON keeps the properties the struct does not declare in AdditionalProperties
+func (g *GraphAPIV1UserResponse) UnmarshalJSON(data []byte) error {
+	type user GraphAPIV1UserResponse
+	properties, err := msgraph.UnmarshalResource(data, (*user)(g))
+	g.AdditionalProperties = properties
+	return err
+}
+
+// MarshalJSON writes AdditionalProperties back next to the declared properties
+func (g GraphAPIV1UserResponse) MarshalJSON() ([]byte, error) {
+	type user GraphAPIV1UserResponse
+	return msgraph.MarshalResource(user(g), g.AdditionalProperties)
 }
 
 func (g GraphAPIV1UserResponse) ToString() string {
 	return g.DisplayName
 }
 
+func init() {
+	msgraph.Register(msgraph.Registration{
+		Name:           "users",
+		ODataType:      "#microsoft.graph.user",
+		Aliases:        []string{"u"},
+		ListArgument:   "user name start",
+		API:            UsersResource{},
+		DefaultColumns: []string{"id", "displayName", "userPrincipalName", "mail", "jobTitle"},
+		Filterable:     []string{"userPrincipalName", "mail", "department", "jobTitle", "userType", "employeeId"},
+		Verbs:          []msgraph.Verb{msgraph.VerbList, msgraph.VerbGet, msgraph.VerbDelete},
+	})
+}
+
 // UsersResource UsersResource
 type UsersResource struct{}
 
@@ -54,8 +104,18 @@ func (g UsersResource) toResourcArr(userList GraphAPIV1UserListResponse) []msgra
 	return resources
 }
 
+func (g UsersResource) ConvertToResource(body []byte) msgraph.Resource {
+	var user GraphAPIV1UserResponse
+	err := json.Unmarshal(body, &user)
+	helpers.ErrorHandlerFatal("JSON unmarshalling of response body failed:", err)
+
+	log.Tracef("UNMASHALLED OBJECT: %+v", user)
+
+	return user
+}
+
 func (g UsersResource) CreateRequestPath(context cli.Context, args cli.Args) string {
-	return "/v1.0/users"
+	return "/users"
 }
 
 func (g UsersResource) CreateQueryParams(context cli.Context, args cli.Args) url.Values {